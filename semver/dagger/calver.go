@@ -0,0 +1,290 @@
+// Based on https://calver.org/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Calver is a calendar version. Components missing from its format are zero
+type Calver struct {
+	Format   string
+	Year     int
+	Month    int
+	Week     int
+	Day      int
+	Major    int
+	Minor    int
+	Micro    int
+	Modifier string
+}
+
+func (m *Calver) Json() (string, error) {
+	if m == nil {
+		return "", errors.New("cannot get Calver")
+	}
+	b, err := json.Marshal(*m)
+	if err != nil {
+		fmt.Println(err)
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Longest tokens first, so that YYYY is not read as YY twice
+var calverTokens = []string{"MODIFIER", "MAJOR", "MINOR", "MICRO", "YYYY", "YY", "0Y", "MM", "0M", "WW", "0W", "DD", "0D"}
+
+var calverTokenPatterns = map[string]string{
+	"YYYY":     `\d{4}`,
+	"YY":       `0|[1-9]\d*`,
+	"0Y":       `\d{2,}`,
+	"MM":       `1[0-2]|[1-9]`,
+	"0M":       `1[0-2]|0[1-9]`,
+	"WW":       `5[0-3]|[1-4]\d|[1-9]`,
+	"0W":       `5[0-3]|[1-4]\d|0[1-9]`,
+	"DD":       `3[01]|[12]\d|[1-9]`,
+	"0D":       `3[01]|[12]\d|0[1-9]`,
+	"MAJOR":    `0|[1-9]\d*`,
+	"MINOR":    `0|[1-9]\d*`,
+	"MICRO":    `0|[1-9]\d*`,
+	"MODIFIER": `[0-9A-Za-z][0-9A-Za-z.-]*`,
+}
+
+// tokenizeCalver splits a format such as YYYY.0M.MICRO into tokens and literal separators
+func tokenizeCalver(format string) []string {
+	var tokens []string
+	for len(format) > 0 {
+		token := format[:1]
+		for _, t := range calverTokens {
+			if strings.HasPrefix(format, t) {
+				token = t
+				break
+			}
+		}
+		tokens = append(tokens, token)
+		format = format[len(token):]
+	}
+	return tokens
+}
+
+// ParseCalver reads a calendar version, checking its date is real
+func (r *Semver) ParseCalver(
+	ver string,
+	// CalVer format, e.g. YYYY.MM.DD, YY.0M.MICRO, YYYY.WW
+	format string,
+) (*Calver, error) {
+	tokens := tokenizeCalver(format)
+	var expr strings.Builder
+	var fields []string
+	for _, token := range tokens {
+		if pattern, ok := calverTokenPatterns[token]; ok {
+			expr.WriteString("(" + pattern + ")")
+			fields = append(fields, token)
+		} else {
+			expr.WriteString(regexp.QuoteMeta(token))
+		}
+	}
+	if len(fields) == 0 {
+		return nil, errors.New(fmt.Sprintf("Invalid CalVer format: %s", format))
+	}
+
+	match := regexp.MustCompile("^" + expr.String() + "$").FindStringSubmatch(ver)
+	if match == nil {
+		return nil, errors.New(fmt.Sprintf("Cannot parse CalVer %s as %s", ver, format))
+	}
+
+	cal := &Calver{Format: format}
+	for i, field := range fields {
+		value := match[i+1]
+		if field == "MODIFIER" {
+			cal.Modifier = value
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case "YYYY":
+			cal.Year = n
+		case "YY", "0Y":
+			cal.Year = 2000 + n
+		case "MM", "0M":
+			cal.Month = n
+		case "WW", "0W":
+			cal.Week = n
+		case "DD", "0D":
+			cal.Day = n
+		case "MAJOR":
+			cal.Major = n
+		case "MINOR":
+			cal.Minor = n
+		case "MICRO":
+			cal.Micro = n
+		}
+	}
+
+	if cal.Day != 0 {
+		if cal.Month == 0 {
+			return nil, errors.New(fmt.Sprintf("CalVer format %s has a day but no month", format))
+		}
+		date := time.Date(cal.Year, time.Month(cal.Month), cal.Day, 0, 0, 0, 0, time.UTC)
+		if date.Day() != cal.Day {
+			return nil, errors.New(fmt.Sprintf("Invalid date in CalVer %s", ver))
+		}
+	}
+	if cal.Week != 0 && cal.Year != 0 {
+		// December 28th always falls in the last ISO week of its year, the 52nd or the 53rd
+		if _, weeks := time.Date(cal.Year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek(); cal.Week > weeks {
+			return nil, errors.New(fmt.Sprintf("Invalid week in CalVer %s, %d has %d ISO weeks", ver, cal.Year, weeks))
+		}
+	}
+	return cal, nil
+}
+
+func (r *Semver) ValidateCalver(
+	ver string,
+	// CalVer format, e.g. YYYY.MM.DD, YY.0M.MICRO, YYYY.WW
+	format string,
+) bool {
+	_, err := r.ParseCalver(ver, format)
+	if err != nil {
+		fmt.Printf("CalVer not valid: %s", ver)
+		fmt.Println(err)
+		return false
+	}
+	return true
+}
+
+// NextCalver returns the version for the given date. Releasing again in the same period increments the
+// lowest MAJOR/MINOR/MICRO counter; a new period resets MINOR and MICRO.
+func (r *Semver) NextCalver(
+	// CalVer format, e.g. YYYY.MM.DD, YY.0M.MICRO, YYYY.WW
+	format string,
+	// Previous version
+	// +optional
+	previous string,
+	// Release date as YYYY-MM-DD, defaults to today (UTC)
+	// +optional
+	date string,
+) (string, error) {
+	now := time.Now().UTC()
+	if date != "" {
+		parsed, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			return "", err
+		}
+		now = parsed
+	}
+
+	tokens := tokenizeCalver(format)
+	next := &Calver{Format: format, Year: now.Year(), Month: int(now.Month()), Day: now.Day()}
+	if strings.Contains(format, "WW") || strings.Contains(format, "0W") {
+		next.Year, next.Week = now.ISOWeek()
+	}
+
+	if previous != "" {
+		prev, err := r.ParseCalver(previous, format)
+		if err != nil {
+			return "", err
+		}
+		next.Major = prev.Major
+
+		switch cmp := comparePeriods(prev.period(tokens), next.period(tokens)); {
+		case cmp == 0:
+			next.Minor, next.Micro = prev.Minor, prev.Micro
+			switch {
+			case containsToken(tokens, "MICRO"):
+				next.Micro++
+			case containsToken(tokens, "MINOR"):
+				next.Minor++
+			case containsToken(tokens, "MAJOR"):
+				next.Major++
+			default:
+				return "", errors.New(fmt.Sprintf("CalVer format %s has no counter to release %s again", format, previous))
+			}
+		case cmp > 0:
+			return "", errors.New(fmt.Sprintf("Previous CalVer %s is newer than %s", previous, now.Format(time.DateOnly)))
+		}
+	}
+
+	return next.render(tokens), nil
+}
+
+func (m *Calver) render(tokens []string) string {
+	var out strings.Builder
+	for _, token := range tokens {
+		switch token {
+		case "YYYY":
+			out.WriteString(strconv.Itoa(m.Year))
+		case "YY":
+			out.WriteString(strconv.Itoa(m.Year - 2000))
+		case "0Y":
+			out.WriteString(fmt.Sprintf("%02d", m.Year-2000))
+		case "MM":
+			out.WriteString(strconv.Itoa(m.Month))
+		case "0M":
+			out.WriteString(fmt.Sprintf("%02d", m.Month))
+		case "WW":
+			out.WriteString(strconv.Itoa(m.Week))
+		case "0W":
+			out.WriteString(fmt.Sprintf("%02d", m.Week))
+		case "DD":
+			out.WriteString(strconv.Itoa(m.Day))
+		case "0D":
+			out.WriteString(fmt.Sprintf("%02d", m.Day))
+		case "MAJOR":
+			out.WriteString(strconv.Itoa(m.Major))
+		case "MINOR":
+			out.WriteString(strconv.Itoa(m.Minor))
+		case "MICRO":
+			out.WriteString(strconv.Itoa(m.Micro))
+		case "MODIFIER":
+		default:
+			out.WriteString(token)
+		}
+	}
+	return strings.TrimRight(out.String(), ".-_")
+}
+
+// period holds the date components the format is made of
+func (m *Calver) period(tokens []string) []int {
+	var period []int
+	for _, token := range tokens {
+		switch token {
+		case "YYYY", "YY", "0Y":
+			period = append(period, m.Year)
+		case "MM", "0M":
+			period = append(period, m.Month)
+		case "WW", "0W":
+			period = append(period, m.Week)
+		case "DD", "0D":
+			period = append(period, m.Day)
+		}
+	}
+	return period
+}
+
+func comparePeriods(a []int, b []int) int {
+	for i := range a {
+		if c := compareInts(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func containsToken(tokens []string, token string) bool {
+	for _, t := range tokens {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+)
+import "github.com/BurntSushi/toml"
+
+type cargoManifest struct {
+	Package *struct {
+		Name string
+		// Either a string or {workspace = true}
+		Version any
+	}
+	Workspace *struct {
+		Members []string
+		Exclude []string
+		Package struct {
+			Version string
+		}
+	}
+}
+
+func (r *Semver) getVersionFromCargo(ctx context.Context, src *Directory) *string {
+	contents, err := src.File("Cargo.toml").Contents(ctx)
+	if err != nil {
+		fmt.Println("Cannot find a Cargo.toml")
+		return nil
+	}
+	root, err := parseCargoManifest(contents)
+	if err != nil {
+		fmt.Println("Cannot parse Cargo.toml")
+		fmt.Println(err)
+		return nil
+	}
+
+	// A virtual manifest has no [package], only the version shared by its members
+	if root.Package == nil {
+		if root.Workspace != nil && root.Workspace.Package.Version != "" {
+			return &root.Workspace.Package.Version
+		}
+		fmt.Println("Cannot find a version in Cargo.toml")
+		return nil
+	}
+
+	version, err := root.version(root)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	return &version
+}
+
+func parseCargoManifest(contents string) (*cargoManifest, error) {
+	var manifest cargoManifest
+	if _, err := toml.Decode(contents, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// version resolves the package version, inheriting it from the workspace root when version.workspace = true
+func (m *cargoManifest) version(root *cargoManifest) (string, error) {
+	switch version := m.Package.Version.(type) {
+	case string:
+		return version, nil
+	case map[string]any:
+		if inherit, _ := version["workspace"].(bool); inherit {
+			if root.Workspace == nil || root.Workspace.Package.Version == "" {
+				return "", errors.New(fmt.Sprintf("Crate %s inherits a version missing from [workspace.package]", m.Package.Name))
+			}
+			return root.Workspace.Package.Version, nil
+		}
+	case nil:
+		// Cargo defaults to 0.0.0 when the version is omitted
+		return "0.0.0", nil
+	}
+	return "", errors.New(fmt.Sprintf("Cannot read version of crate %s", m.Package.Name))
+}
+
+// cargoWorkspaceVersions lists the root crate, if any, and every workspace member crate
+func cargoWorkspaceVersions(rootContents string, readFile func(string) (string, error), glob func(string) ([]string, error)) ([]*ModuleVersion, error) {
+	root, err := parseCargoManifest(rootContents)
+	if err != nil {
+		return nil, errors.Join(errors.New("Cannot parse Cargo.toml"), err)
+	}
+
+	var modules []*ModuleVersion
+	if root.Package != nil {
+		version, err := root.version(root)
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, &ModuleVersion{Path: "Cargo.toml", Name: root.Package.Name, Version: version})
+	}
+	if root.Workspace == nil {
+		return modules, nil
+	}
+
+	manifests, err := cargoMemberManifests(root, glob)
+	if err != nil {
+		return nil, err
+	}
+	for _, manifest := range manifests {
+		contents, err := readFile(manifest)
+		if err != nil {
+			return nil, errors.Join(errors.New(fmt.Sprintf("Cannot read %s", manifest)), err)
+		}
+		crate, err := parseCargoManifest(contents)
+		if err != nil {
+			return nil, errors.Join(errors.New(fmt.Sprintf("Cannot parse %s", manifest)), err)
+		}
+		if crate.Package == nil {
+			continue
+		}
+		version, err := crate.version(root)
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, &ModuleVersion{Path: manifest, Name: crate.Package.Name, Version: version})
+	}
+	return modules, nil
+}
+
+// cargoMemberManifests lists the Cargo.toml of the workspace members, sorted and without the excluded ones
+func cargoMemberManifests(root *cargoManifest, glob func(string) ([]string, error)) ([]string, error) {
+	if root.Workspace == nil {
+		return nil, nil
+	}
+	var manifests []string
+	seen := make(map[string]bool)
+	for _, member := range root.Workspace.Members {
+		matches, err := glob(path.Join(member, "Cargo.toml"))
+		if err != nil {
+			return nil, err
+		}
+		for _, manifest := range matches {
+			if !seen[manifest] && !isCargoExcluded(path.Dir(manifest), root.Workspace.Exclude) {
+				seen[manifest] = true
+				manifests = append(manifests, manifest)
+			}
+		}
+	}
+	sort.Strings(manifests)
+	return manifests, nil
+}
+
+func isCargoExcluded(dir string, exclude []string) bool {
+	for _, pattern := range exclude {
+		if matched, _ := path.Match(path.Clean(pattern), dir); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ReleaseNotes is a changelog of the commits between two revisions
+type ReleaseNotes struct {
+	// Version the changelog is for
+	Version string
+	// Changelog as Markdown
+	Markdown *File
+	// Changelog as JSON
+	Json string
+}
+
+type changelogEntry struct {
+	Hash    string `json:"hash"`
+	Scope   string `json:"scope,omitempty"`
+	Subject string `json:"subject"`
+}
+
+type changelogSection struct {
+	Type    string           `json:"type"`
+	Title   string           `json:"title"`
+	Commits []changelogEntry `json:"commits"`
+}
+
+type changelogBreakingChange struct {
+	changelogEntry
+	Note string `json:"note,omitempty"`
+}
+
+type changelog struct {
+	Version  string                    `json:"version"`
+	Previous string                    `json:"previous,omitempty"`
+	From     string                    `json:"from,omitempty"`
+	To       string                    `json:"to"`
+	Date     string                    `json:"date"`
+	Bump     string                    `json:"bump"`
+	Breaking []changelogBreakingChange `json:"breaking"`
+	Sections []changelogSection        `json:"sections"`
+}
+
+var changelogTypes = []struct {
+	Type  string
+	Title string
+}{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance Improvements"},
+	{"revert", "Reverts"},
+	{"refactor", "Code Refactoring"},
+	{"docs", "Documentation"},
+	{"style", "Styles"},
+	{"test", "Tests"},
+	{"build", "Build System"},
+	{"ci", "Continuous Integration"},
+	{"chore", "Chores"},
+}
+
+// Changelog groups the Conventional Commits between two revisions into release notes
+func (r *Semver) Changelog(ctx context.Context,
+	// Path to the source directory, including .git
+	src *Directory,
+
+	// Tag or commit to start after. Defaults to the latest release tag reachable from "to"
+	// +optional
+	from string,
+
+	// Tag or commit to end at
+	// +optional
+	// +default="HEAD"
+	to string,
+
+	// Prefix of the release tags, e.g. v, api/v, release-
+	// +optional
+	// +default="v"
+	tagPrefix string,
+) (*ReleaseNotes, error) {
+	if to == "" {
+		to = "HEAD"
+	}
+
+	gitSrc := newGitSource(src, false)
+	defer gitSrc.close()
+	repo, err := gitSrc.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	toHash, err := resolveRevision(repo, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous *Version
+	if from == "" {
+		merged, err := gitTags(repo, to)
+		if err != nil {
+			return nil, err
+		}
+		// "to" itself is not a previous release when it is tagged
+		var tags []string
+		for _, tag := range merged {
+			if tag.Commit != toHash {
+				tags = append(tags, tag.Name)
+			}
+		}
+		from, previous = r.latestTag(tags, tagPrefix, false)
+		if previous == nil {
+			previous = &Version{}
+		}
+	} else {
+		previous = r.tagVersion(from, tagPrefix)
+	}
+
+	commits, err := commitsSince(repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	toCommit, err := repo.CommitObject(toHash)
+	if err != nil {
+		return nil, err
+	}
+
+	bump, _ := classifyCommits(commits)
+	log := buildChangelog(commits, previous, bump)
+	log.From = from
+	log.To = to
+	log.Date = toCommit.Committer.When.Format("2006-01-02")
+	if version := r.tagVersion(to, tagPrefix); version != nil {
+		log.Version = version.String()
+	}
+
+	b, err := json.Marshal(log)
+	if err != nil {
+		return nil, err
+	}
+	return &ReleaseNotes{
+		Version:  log.Version,
+		Markdown: dag.Directory().WithNewFile("CHANGELOG.md", log.markdown()).File("CHANGELOG.md"),
+		Json:     string(b),
+	}, nil
+}
+
+// tagVersion returns the version held by a tag such as v1.2.3 for the prefix v, nil for any other revision
+func (r *Semver) tagVersion(tag string, prefix string) *Version {
+	if ver, ok := strings.CutPrefix(tag, prefix); ok && semverPattern.MatchString(ver) {
+		if parsed, err := r.Parse(ver); err == nil {
+			return parsed
+		}
+	}
+	return nil
+}
+
+// buildChangelog groups the commits by type, the version being the previous one bumped accordingly
+func buildChangelog(commits []conventionalCommit, previous *Version, bump int) *changelog {
+	log := &changelog{
+		Version:  "Unreleased",
+		Bump:     bumpNames[bump],
+		Breaking: []changelogBreakingChange{},
+		Sections: []changelogSection{},
+	}
+	if previous != nil {
+		log.Previous = previous.String()
+		switch bump {
+		case bumpMajor:
+			log.Version = previous.BumpMajor().String()
+		case bumpMinor:
+			log.Version = previous.BumpMinor().String()
+		case bumpPatch:
+			log.Version = previous.BumpPatch().String()
+		}
+	}
+
+	for _, t := range changelogTypes {
+		section := changelogSection{Type: t.Type, Title: t.Title}
+		for _, c := range commits {
+			if c.Type == t.Type {
+				section.Commits = append(section.Commits, changelogEntry{Hash: c.Hash, Scope: c.Scope, Subject: c.Subject})
+			}
+		}
+		if len(section.Commits) > 0 {
+			log.Sections = append(log.Sections, section)
+		}
+	}
+	for _, c := range commits {
+		if c.Breaking {
+			note := c.BreakingNote
+			if note == "" {
+				note = c.Subject
+			}
+			log.Breaking = append(log.Breaking, changelogBreakingChange{
+				changelogEntry: changelogEntry{Hash: c.Hash, Scope: c.Scope, Subject: c.Subject},
+				Note:           note,
+			})
+		}
+	}
+	return log
+}
+
+func (log *changelog) markdown() string {
+	var md strings.Builder
+	fmt.Fprintf(&md, "## %s (%s)\n", log.Version, log.Date)
+
+	if len(log.Breaking) > 0 {
+		md.WriteString("\n### ⚠ BREAKING CHANGES\n\n")
+		for _, b := range log.Breaking {
+			fmt.Fprintf(&md, "* %s%s (%s)\n", scopePrefix(b.Scope), b.Note, b.Hash)
+		}
+	}
+	for _, section := range log.Sections {
+		fmt.Fprintf(&md, "\n### %s\n\n", section.Title)
+		for _, c := range section.Commits {
+			fmt.Fprintf(&md, "* %s%s (%s)\n", scopePrefix(c.Scope), c.Subject, c.Hash)
+		}
+	}
+	if len(log.Breaking) == 0 && len(log.Sections) == 0 {
+		md.WriteString("\nNo notable changes.\n")
+	}
+	return md.String()
+}
+
+func scopePrefix(scope string) string {
+	if scope == "" {
+		return ""
+	}
+	return fmt.Sprintf("**%s:** ", scope)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// VersionCheck is the outcome of checking a version against SemVer 2.0.0
+type VersionCheck struct {
+	Version  string
+	Valid    bool
+	Problems []*VersionProblem
+	// The version with every suggested fix applied, empty when valid or when no fix makes it valid
+	Suggestion string
+}
+
+// VersionProblem is a single SemVer violation
+type VersionProblem struct {
+	// major, minor, patch, prerelease or build
+	Component string
+	// Byte offset of the problem in the version, starting at 0
+	Offset     int
+	Message    string
+	Suggestion string
+}
+
+func (m *VersionCheck) Json() (string, error) {
+	if m == nil {
+		return "", errors.New("cannot get VersionCheck")
+	}
+	b, err := json.Marshal(*m)
+	if err != nil {
+		fmt.Println(err)
+		return "", err
+	}
+	return string(b), nil
+}
+
+var coreComponents = []string{"major", "minor", "patch"}
+
+// Check validates a version like Validate does, but reports which component is wrong, where, and how to fix it
+func (r *Semver) Check(ver string) *VersionCheck {
+	check := &VersionCheck{Version: ver}
+	problem := func(component string, offset int, suggestion string, format string, args ...any) {
+		check.Problems = append(check.Problems, &VersionProblem{
+			Component:  component,
+			Offset:     offset,
+			Message:    fmt.Sprintf(format, args...),
+			Suggestion: suggestion,
+		})
+	}
+
+	rest, build, hasBuild := strings.Cut(ver, "+")
+	core, pre, hasPre := strings.Cut(rest, "-")
+
+	offset := 0
+	if strings.HasPrefix(core, "v") || strings.HasPrefix(core, "V") {
+		problem("major", 0, "Remove the prefix", "The prefix %q is not part of a version", core[:1])
+		core, offset = core[1:], 1
+	}
+
+	coreEnd := offset + len(core)
+	var fixed []string
+	parts := strings.Split(core, ".")
+	for i, part := range parts {
+		if i >= len(coreComponents) {
+			problem("patch", offset-1, "Remove the extra numbers or move them to the build metadata",
+				"Found %d dot-separated numbers, a version has exactly 3: major.minor.patch", len(parts))
+			break
+		}
+		fixed = append(fixed, checkNumber(coreComponents[i], part, offset, problem))
+		offset += len(part) + 1
+	}
+	for i := len(parts); i < len(coreComponents); i++ {
+		problem(coreComponents[i], coreEnd, "Append .0", "Missing %s version", coreComponents[i])
+		fixed = append(fixed, "0")
+	}
+	suggestion := strings.Join(fixed, ".")
+
+	if hasPre {
+		if ids := checkIdentifiers("prerelease", pre, len(rest)-len(pre), true, problem); ids != "" {
+			suggestion += "-" + ids
+		}
+	}
+	if hasBuild {
+		if ids := checkIdentifiers("build", build, len(rest)+1, false, problem); ids != "" {
+			suggestion += "+" + ids
+		}
+	}
+
+	check.Valid = len(check.Problems) == 0
+	if check.Valid && !semverPattern.MatchString(ver) {
+		// Not expected, but the report must never claim more than Validate does
+		problem("major", 0, "", "Not a SemVer version")
+		check.Valid = false
+	}
+	if !check.Valid && semverPattern.MatchString(suggestion) {
+		check.Suggestion = suggestion
+	}
+	return check
+}
+
+// checkNumber checks a major, minor or patch number starting at offset and returns its closest valid value
+func checkNumber(component string, s string, offset int, problem func(string, int, string, string, ...any)) string {
+	if s == "" {
+		problem(component, offset, "Use 0", "Empty %s version", component)
+		return "0"
+	}
+
+	var digits strings.Builder
+	for i, c := range s {
+		if c < '0' || c > '9' {
+			problem(component, offset+i, "Remove the character", "Illegal character %q in the %s version, only digits are allowed", c, component)
+			continue
+		}
+		digits.WriteRune(c)
+	}
+	number := digits.String()
+	if number == "" {
+		return "0"
+	}
+	if len(number) > 1 && number[0] == '0' {
+		trimmed := strings.TrimLeft(number, "0")
+		if trimmed == "" {
+			trimmed = "0"
+		}
+		problem(component, offset, fmt.Sprintf("Use %s", trimmed), "Leading zero in the %s version %s", component, number)
+		number = trimmed
+	}
+	return number
+}
+
+// checkIdentifiers checks the dot-separated prerelease or build identifiers starting at offset
+// and returns their closest valid value. Only prerelease identifiers forbid leading zeros.
+func checkIdentifiers(component string, s string, offset int, numericRule bool, problem func(string, int, string, string, ...any)) string {
+	separator := "+"
+	if numericRule {
+		separator = "-"
+	}
+	if s == "" {
+		problem(component, offset-1, fmt.Sprintf("Remove the trailing %s", separator), "Empty %s", component)
+		return ""
+	}
+
+	var fixed []string
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			problem(component, offset, "Remove the extra dot", "Empty %s identifier", component)
+			offset++
+			continue
+		}
+		for i, c := range id {
+			if !isIdentifierChar(c) {
+				problem(component, offset+i, "Replace it with -", "Illegal character %q in the %s, only [0-9A-Za-z-] are allowed", c, component)
+			}
+		}
+		valid := identifierInvalidChars.ReplaceAllString(id, "-")
+		if numericRule && isNumeric(valid) && len(valid) > 1 && valid[0] == '0' {
+			trimmed := strings.TrimLeft(valid, "0")
+			if trimmed == "" {
+				trimmed = "0"
+			}
+			problem(component, offset, fmt.Sprintf("Use %s", trimmed), "Leading zero in the numeric %s identifier %s", component, valid)
+			valid = trimmed
+		}
+		fixed = append(fixed, valid)
+		offset += len(id) + 1
+	}
+	return strings.Join(fixed, ".")
+}
+
+func isIdentifierChar(c rune) bool {
+	return c == '-' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CoercedVersion is a valid SemVer derived from a loose version string
+type CoercedVersion struct {
+	Input   string
+	Version string
+	// What was changed to turn the input into the version, empty when it already was one
+	Changes []string
+}
+
+func (m *CoercedVersion) Json() (string, error) {
+	if m == nil {
+		return "", errors.New("cannot get CoercedVersion")
+	}
+	b, err := json.Marshal(*m)
+	if err != nil {
+		fmt.Println(err)
+		return "", err
+	}
+	return string(b), nil
+}
+
+// looseVersionPattern finds the first run of dot-separated numbers, with up to 3 significant ones,
+// followed by an optional prerelease and build metadata
+var looseVersionPattern = regexp.MustCompile(`(\d+)(?:\.(\d+))?(?:\.(\d+))?((?:\.\d+)*)(?:-([0-9A-Za-z_.-]*[0-9A-Za-z]))?(?:\+([0-9A-Za-z_.-]*[0-9A-Za-z]))?`)
+
+// Coerce turns a loose version such as v1.2, 1.2.3.4, 01.02.03, 1.0-SNAPSHOT, release-2.3 or nginx 1.25.3 into a valid SemVer
+func (r *Semver) Coerce(input string) (*CoercedVersion, error) {
+	loc := looseVersionPattern.FindStringSubmatchIndex(input)
+	if loc == nil {
+		return nil, errors.New(fmt.Sprintf("No version number found in %q", input))
+	}
+	group := func(i int) string {
+		if loc[2*i] < 0 {
+			return ""
+		}
+		return input[loc[2*i]:loc[2*i+1]]
+	}
+
+	coerced := &CoercedVersion{Input: input}
+	changed := func(format string, args ...any) {
+		coerced.Changes = append(coerced.Changes, fmt.Sprintf(format, args...))
+	}
+
+	if leading := input[:loc[0]]; leading != "" {
+		changed("Dropped the leading %q", leading)
+	}
+	if trailing := input[loc[1]:]; trailing != "" {
+		changed("Dropped the trailing %q", trailing)
+	}
+
+	numbers := make([]string, len(coreComponents))
+	for i, component := range coreComponents {
+		number := group(i + 1)
+		switch {
+		case number == "":
+			number = "0"
+			changed("Added the missing %s version 0", component)
+		case len(number) > 1 && number[0] == '0':
+			number = strings.TrimLeft(number, "0")
+			if number == "" {
+				number = "0"
+			}
+			changed("Removed the leading zeros of the %s version %s", component, group(i+1))
+		}
+		numbers[i] = number
+	}
+
+	prerelease := group(5)
+	if sanitized := sanitizeIdentifiers(prerelease); sanitized != prerelease {
+		changed("Sanitized the prerelease %q to %q", prerelease, sanitized)
+		prerelease = sanitized
+	}
+
+	build := group(6)
+	if sanitized := sanitizeBuildMetadata(build); sanitized != build {
+		changed("Sanitized the build metadata %q to %q", build, sanitized)
+		build = sanitized
+	}
+	// Rather than losing them, extra numbers such as the 4 of 1.2.3.4 go to the build metadata
+	if extra := strings.TrimPrefix(group(4), "."); extra != "" {
+		changed("Moved the extra version numbers %s to the build metadata", extra)
+		build = strings.Trim(extra+"."+build, ".")
+	}
+
+	coerced.Version = strings.Join(numbers, ".")
+	if prerelease != "" {
+		coerced.Version += "-" + prerelease
+	}
+	coerced.Version = r.ConcatVersion(coerced.Version, build)
+	if !semverPattern.MatchString(coerced.Version) {
+		return nil, errors.New(fmt.Sprintf("Cannot coerce %q into a SemVer, got %s", input, coerced.Version))
+	}
+	return coerced, nil
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A comparator matches versions against a single bound. A nil version matches anything.
+type comparator struct {
+	op  string
+	ver *Version
+}
+
+// A partial version, e.g. 1, 1.2, 1.x or 1.2.3-rc.1
+type partialVersion struct {
+	Version
+	// Number of leading components given, 0 for *
+	parts int
+}
+
+var partialPattern = regexp.MustCompile(`^[v=]*(?P<major>0|[1-9]\d*|[xX*])(?:\.(?P<minor>0|[1-9]\d*|[xX*]))?(?:\.(?P<patch>0|[1-9]\d*|[xX*]))?(?:-(?P<prerelease>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?(?:\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+var operatorPattern = regexp.MustCompile(`^(?P<op><=|>=|<|>|=|~>|~|\^)?(?P<ver>.*)$`)
+var operatorSpacing = regexp.MustCompile(`(<=|>=|<|>|=|~>|~|\^)\s+`)
+var hyphenPattern = regexp.MustCompile(`^(?P<from>\S+)\s+-\s+(?P<to>\S+)$`)
+
+// Satisfies tells whether the version matches an npm-style range, e.g. ^1.2.0, ~1.2, >=1.0.0 <2.0.0, 1.x, 1.2.3 - 2.3.4 or a || union.
+// A prerelease only matches when a comparator of the same major.minor.patch has a prerelease too.
+func (r *Semver) Satisfies(
+	version string,
+	// npm-style range
+	constraint string,
+) (bool, error) {
+	ver, err := r.Parse(version)
+	if err != nil {
+		return false, err
+	}
+	sets, err := parseConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	return satisfies(ver, sets), nil
+}
+
+// MaxSatisfying returns the highest version that matches the npm-style range
+func (r *Semver) MaxSatisfying(
+	versions []string,
+	// npm-style range
+	constraint string,
+) (string, error) {
+	sets, err := parseConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+
+	var best *Version
+	bestStr := ""
+	for _, version := range versions {
+		ver, err := r.Parse(version)
+		if err != nil {
+			return "", err
+		}
+		if satisfies(ver, sets) && (best == nil || compareVersions(ver, best) > 0) {
+			best, bestStr = ver, version
+		}
+	}
+
+	if best == nil {
+		return "", errors.New(fmt.Sprintf("No version satisfies %s", constraint))
+	}
+	return bestStr, nil
+}
+
+func satisfies(ver *Version, sets [][]comparator) bool {
+	for _, set := range sets {
+		if satisfiesSet(ver, set) {
+			return true
+		}
+	}
+	return false
+}
+
+func satisfiesSet(ver *Version, set []comparator) bool {
+	for _, c := range set {
+		if !c.matches(ver) {
+			return false
+		}
+	}
+	if ver.Prerelease == "" {
+		return true
+	}
+
+	// Prereleases are only opted into for the exact major.minor.patch a comparator names
+	for _, c := range set {
+		if c.ver != nil && c.ver.Prerelease != "" &&
+			c.ver.Maj == ver.Maj && c.ver.Min == ver.Min && c.ver.Patch == ver.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+func (c comparator) matches(ver *Version) bool {
+	if c.ver == nil {
+		return true
+	}
+	cmp := compareVersions(ver, c.ver)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// parseConstraint returns the comparator sets of a || union
+func parseConstraint(constraint string) ([][]comparator, error) {
+	var sets [][]comparator
+	for _, rng := range strings.Split(constraint, "||") {
+		rng = strings.TrimSpace(operatorSpacing.ReplaceAllString(strings.TrimSpace(rng), "$1"))
+
+		var set []comparator
+		if match := hyphenPattern.FindStringSubmatch(rng); match != nil {
+			hyphen, err := desugarHyphen(match[hyphenPattern.SubexpIndex("from")], match[hyphenPattern.SubexpIndex("to")])
+			if err != nil {
+				return nil, err
+			}
+			set = hyphen
+		} else {
+			for _, token := range strings.Fields(rng) {
+				comparators, err := desugarComparator(token)
+				if err != nil {
+					return nil, err
+				}
+				set = append(set, comparators...)
+			}
+		}
+
+		if len(set) == 0 {
+			set = []comparator{{}}
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+func parsePartial(ver string) (*partialVersion, error) {
+	groups := extractGroups(partialPattern, ver)
+	if len(groups) == 0 {
+		return nil, errors.New(fmt.Sprintf("Cannot parse version range %s", ver))
+	}
+
+	p := &partialVersion{Version: Version{Prerelease: groups["prerelease"]}}
+	for _, part := range []struct {
+		value string
+		dest  *int
+	}{{groups["major"], &p.Maj}, {groups["minor"], &p.Min}, {groups["patch"], &p.Patch}} {
+		if part.value == "" || strings.ContainsAny(part.value, "xX*") {
+			break
+		}
+		n, err := strconv.Atoi(part.value)
+		if err != nil {
+			return nil, err
+		}
+		*part.dest = n
+		p.parts++
+	}
+	if p.parts < 3 {
+		p.Prerelease = ""
+	}
+	return p, nil
+}
+
+// floor is the lowest version the partial matches, e.g. 1.2 -> 1.2.0
+func (p *partialVersion) floor() *Version {
+	v := p.Version
+	return &v
+}
+
+// next is the lowest version above the partial, as a prerelease bound, e.g. 1.2 -> 1.3.0-0
+func (p *partialVersion) next() *Version {
+	switch p.parts {
+	case 1:
+		return &Version{Maj: p.Maj + 1, Prerelease: "0"}
+	case 2:
+		return &Version{Maj: p.Maj, Min: p.Min + 1, Prerelease: "0"}
+	default:
+		return &Version{Maj: p.Maj, Min: p.Min, Patch: p.Patch + 1, Prerelease: "0"}
+	}
+}
+
+func desugarHyphen(from string, to string) ([]comparator, error) {
+	lower, err := parsePartial(from)
+	if err != nil {
+		return nil, err
+	}
+	upper, err := parsePartial(to)
+	if err != nil {
+		return nil, err
+	}
+
+	var set []comparator
+	if lower.parts > 0 {
+		set = append(set, comparator{">=", lower.floor()})
+	}
+	switch upper.parts {
+	case 0:
+	case 3:
+		set = append(set, comparator{"<=", upper.floor()})
+	default:
+		set = append(set, comparator{"<", upper.next()})
+	}
+	return set, nil
+}
+
+func desugarComparator(token string) ([]comparator, error) {
+	groups := extractGroups(operatorPattern, token)
+	op := groups["op"]
+	p, err := parsePartial(groups["ver"])
+	if err != nil {
+		return nil, err
+	}
+
+	none := []comparator{{"<", &Version{Prerelease: "0"}}}
+	if p.parts == 0 {
+		if op == "<" || op == ">" {
+			return none, nil
+		}
+		return []comparator{{}}, nil
+	}
+
+	switch op {
+	case "~", "~>":
+		upper := &Version{Maj: p.Maj + 1, Prerelease: "0"}
+		if p.parts > 1 {
+			upper = &Version{Maj: p.Maj, Min: p.Min + 1, Prerelease: "0"}
+		}
+		return []comparator{{">=", p.floor()}, {"<", upper}}, nil
+	case "^":
+		var upper *Version
+		switch {
+		case p.Maj != 0 || p.parts == 1:
+			upper = &Version{Maj: p.Maj + 1, Prerelease: "0"}
+		case p.Min != 0 || p.parts == 2:
+			upper = &Version{Min: p.Min + 1, Prerelease: "0"}
+		default:
+			upper = &Version{Patch: p.Patch + 1, Prerelease: "0"}
+		}
+		return []comparator{{">=", p.floor()}, {"<", upper}}, nil
+	case ">":
+		if p.parts == 3 {
+			return []comparator{{">", p.floor()}}, nil
+		}
+		next := p.next()
+		next.Prerelease = ""
+		return []comparator{{">=", next}}, nil
+	case ">=":
+		return []comparator{{">=", p.floor()}}, nil
+	case "<":
+		if p.parts == 3 {
+			return []comparator{{"<", p.floor()}}, nil
+		}
+		floor := p.floor()
+		floor.Prerelease = "0"
+		return []comparator{{"<", floor}}, nil
+	case "<=":
+		if p.parts == 3 {
+			return []comparator{{"<=", p.floor()}}, nil
+		}
+		return []comparator{{"<", p.next()}}, nil
+	default:
+		if p.parts == 3 {
+			return []comparator{{"=", p.floor()}}, nil
+		}
+		return []comparator{{">=", p.floor()}, {"<", p.next()}}, nil
+	}
+}
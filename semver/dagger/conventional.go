@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+import "github.com/go-git/go-git/v5"
+import "github.com/go-git/go-git/v5/plumbing"
+import "github.com/go-git/go-git/v5/plumbing/object"
+
+// VersionBump is the outcome of a Conventional Commits analysis
+type VersionBump struct {
+	// Next version
+	Version string
+	// Latest release found in the repository, 0.0.0 when there is none
+	Previous string
+	// Tag holding the previous version, empty when there is none
+	PreviousTag string
+	// One of major, minor, patch or none
+	Bump string
+	// Human readable reason for the bump
+	Reason string
+}
+
+func (m *VersionBump) Json() (string, error) {
+	if m == nil {
+		return "", errors.New("cannot get VersionBump")
+	}
+	b, err := json.Marshal(*m)
+	if err != nil {
+		fmt.Println(err)
+		return "", err
+	}
+	return string(b), nil
+}
+
+type conventionalCommit struct {
+	Hash     string
+	Type     string
+	Scope    string
+	Subject  string
+	Breaking bool
+	// Text of the BREAKING CHANGE footer, if any
+	BreakingNote string
+}
+
+const (
+	bumpNone = iota
+	bumpPatch
+	bumpMinor
+	bumpMajor
+)
+
+var bumpNames = []string{"none", "patch", "minor", "major"}
+
+// NextVersion computes the next release from the Conventional Commits added since the latest release tag
+func (r *Semver) NextVersion(ctx context.Context,
+	// Path to the source directory, including .git
+	src *Directory,
+
+	// Prefix of the release tags, e.g. v, api/v, release-
+	// +optional
+	// +default="v"
+	tagPrefix string,
+
+	// Prerelease channels by branch as pattern=channel rules, first match wins, e.g. main= develop=beta feature/*=alpha.{branch}.
+	// An empty channel is a release, {branch} is the sanitized branch name, the channel gets a .N counter.
+	// +optional
+	policy []string,
+
+	// Branch to apply the policy for, defaults to the checked out branch. Required when HEAD is detached, as in most CI checkouts.
+	// +optional
+	branch string,
+) (*VersionBump, error) {
+	gitSrc := newGitSource(src, false)
+	defer gitSrc.close()
+	repo, err := gitSrc.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := gitTags(repo, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	tag, previous := r.latestTag(tagNames(merged), tagPrefix, false)
+	if previous == nil {
+		previous = &Version{}
+	}
+
+	commits, err := commitsSince(repo, tag, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	bump, reason := classifyCommits(commits)
+	next := previous
+	switch bump {
+	case bumpMajor:
+		next = previous.BumpMajor()
+	case bumpMinor:
+		next = previous.BumpMinor()
+	case bumpPatch:
+		next = previous.BumpPatch()
+	}
+
+	if len(policy) > 0 {
+		base := next
+		// A prerelease must precede its release, so it cannot be based on the version already released
+		if bump == bumpNone {
+			base = previous.BumpPatch()
+		}
+		channelled, err := r.applyPrereleasePolicy(ctx, gitSrc, base, policy, branch, tagPrefix)
+		if err != nil {
+			return nil, err
+		}
+		if channelled != base {
+			next = channelled
+		}
+	}
+
+	return &VersionBump{
+		Version:     next.String(),
+		Previous:    previous.String(),
+		PreviousTag: tag,
+		Bump:        bumpNames[bump],
+		Reason:      reason,
+	}, nil
+}
+
+// commitsSince lists the Conventional Commits reachable from to but not from from, newest first like git log from..to.
+// An empty from lists the whole history.
+func commitsSince(repo *git.Repository, from string, to string) ([]conventionalCommit, error) {
+	excluded := make(map[plumbing.Hash]bool)
+	if from != "" {
+		base, err := resolveRevision(repo, from)
+		if err != nil {
+			return nil, err
+		}
+		if err := walkCommits(repo, base, func(c *object.Commit) { excluded[c.Hash] = true }); err != nil {
+			return nil, err
+		}
+	}
+	head, err := resolveRevision(repo, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var log []*object.Commit
+	err = walkCommits(repo, head, func(c *object.Commit) {
+		if !excluded[c.Hash] {
+			log = append(log, c)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]plumbing.Hash, len(log))
+	for i, c := range log {
+		hashes[i] = c.Hash
+	}
+	abbrev, err := abbrevLength(repo, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []conventionalCommit
+	for _, c := range log {
+		if commit := parseConventionalCommit(c.Hash.String()[:abbrev], c.Message); commit != nil {
+			commits = append(commits, *commit)
+		}
+	}
+	return commits, nil
+}
+
+var conventionalHeader = regexp.MustCompile(`^(?P<type>[a-zA-Z]+)(?:\((?P<scope>[^()]*)\))?(?P<breaking>!)?: (?P<subject>.+)$`)
+var breakingFooter = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: *(?P<note>.*)$`)
+
+// parseConventionalCommit returns nil when the message does not follow https://www.conventionalcommits.org/
+func parseConventionalCommit(hash string, message string) *conventionalCommit {
+	header, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	match := conventionalHeader.FindStringSubmatch(strings.TrimSpace(header))
+	if match == nil {
+		return nil
+	}
+
+	commit := &conventionalCommit{
+		Hash:     hash,
+		Type:     strings.ToLower(match[conventionalHeader.SubexpIndex("type")]),
+		Scope:    match[conventionalHeader.SubexpIndex("scope")],
+		Subject:  match[conventionalHeader.SubexpIndex("subject")],
+		Breaking: match[conventionalHeader.SubexpIndex("breaking")] != "",
+	}
+	if footer := breakingFooter.FindStringSubmatch(body); footer != nil {
+		commit.Breaking = true
+		commit.BreakingNote = strings.TrimSpace(footer[breakingFooter.SubexpIndex("note")])
+	}
+	return commit
+}
+
+func (c *conventionalCommit) bump() int {
+	switch {
+	case c.Breaking:
+		return bumpMajor
+	case c.Type == "feat":
+		return bumpMinor
+	case c.Type == "fix":
+		return bumpPatch
+	default:
+		return bumpNone
+	}
+}
+
+// classifyCommits returns the highest bump required by the commits and the reason for it
+func classifyCommits(commits []conventionalCommit) (int, string) {
+	bump := bumpNone
+	var trigger *conventionalCommit
+	counts := make([]int, len(bumpNames))
+	for i := range commits {
+		b := commits[i].bump()
+		counts[b]++
+		if b > bump {
+			bump, trigger = b, &commits[i]
+		}
+	}
+
+	if trigger == nil {
+		return bumpNone, fmt.Sprintf("No feat, fix or breaking commits among %d commits", len(commits))
+	}
+
+	cause := fmt.Sprintf("%s: %s", trigger.Type, trigger.Subject)
+	if trigger.Breaking {
+		cause = "BREAKING CHANGE in " + cause
+		if trigger.BreakingNote != "" {
+			cause += " (" + trigger.BreakingNote + ")"
+		}
+	}
+	return bump, fmt.Sprintf("%s bump triggered by %s [%s]; %d breaking, %d feat, %d fix, %d other commits",
+		bumpNames[bump], cause, trigger.Hash, counts[bumpMajor], counts[bumpMinor], counts[bumpPatch], counts[bumpNone])
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+import "github.com/go-git/go-git/v5"
+import "github.com/go-git/go-git/v5/plumbing"
+import "github.com/go-git/go-git/v5/plumbing/object"
+
+// describeVersion derives a development version from the closest semver tag, like git describe:
+// 1.2.3 on the tagged commit, 1.2.4-dev.5+g1a2b3c4 five commits later, with a .dirty build suffix for local changes
+func (r *Semver) describeVersion(repo *git.Repository, tagPrefix string) (string, error) {
+	head, err := readGitHead(repo)
+	if err != nil {
+		return "", err
+	}
+	tagged, err := r.semverTagsByCommit(repo, tagPrefix)
+	if err != nil {
+		return "", err
+	}
+
+	// Breadth-first, so that the closest tag wins
+	base := &Version{}
+	var baseCommit *plumbing.Hash
+	queue := []plumbing.Hash{plumbing.NewHash(head.Hash)}
+	seen := map[plumbing.Hash]bool{queue[0]: true}
+	for len(queue) > 0 && baseCommit == nil {
+		var next []plumbing.Hash
+		for _, hash := range queue {
+			if ver, ok := tagged[hash]; ok && (baseCommit == nil || compareVersions(ver, base) > 0) {
+				h := hash
+				base, baseCommit = ver, &h
+			}
+			commit, err := repo.CommitObject(hash)
+			if err != nil {
+				return "", err
+			}
+			for _, parent := range commit.ParentHashes {
+				if !seen[parent] {
+					seen[parent] = true
+					next = append(next, parent)
+				}
+			}
+		}
+		queue = next
+	}
+
+	distance, err := commitDistance(repo, plumbing.NewHash(head.Hash), baseCommit)
+	if err != nil {
+		return "", err
+	}
+	dirty, err := isDirty(repo)
+	if err != nil {
+		return "", err
+	}
+
+	if distance == 0 && !dirty {
+		return (&Semver{}).Build(base.Maj, base.Min, base.Patch, base.Prerelease, ""), nil
+	}
+
+	dev := &Version{Maj: base.Maj, Min: base.Min, Patch: base.Patch + 1, Prerelease: fmt.Sprintf("dev.%d", distance)}
+	if base.Prerelease != "" {
+		dev.Patch = base.Patch
+		dev.Prerelease = fmt.Sprintf("%s.dev.%d", base.Prerelease, distance)
+	}
+	dev.Build = "g" + head.ShortHash
+	if dirty {
+		dev.Build += ".dirty"
+	}
+	return dev.String(), nil
+}
+
+// semverTagsByCommit maps the tagged commits to the highest version they are tagged with
+func (r *Semver) semverTagsByCommit(repo *git.Repository, tagPrefix string) (map[plumbing.Hash]*Version, error) {
+	tags, err := gitTags(repo, "")
+	if err != nil {
+		return nil, err
+	}
+	tagged := make(map[plumbing.Hash]*Version)
+	for _, tag := range tags {
+		name, ok := strings.CutPrefix(tag.Name, tagPrefix)
+		if !ok || !semverPattern.MatchString(name) {
+			continue
+		}
+		ver, err := r.Parse(name)
+		if err != nil {
+			continue
+		}
+		if current, ok := tagged[tag.Commit]; !ok || compareVersions(ver, current) > 0 {
+			tagged[tag.Commit] = ver
+		}
+	}
+	return tagged, nil
+}
+
+// commitDistance counts the commits reachable from head but not from base, all of them without a base
+func commitDistance(repo *git.Repository, head plumbing.Hash, base *plumbing.Hash) (int, error) {
+	excluded := make(map[plumbing.Hash]bool)
+	if base != nil {
+		if err := walkCommits(repo, *base, func(c *object.Commit) { excluded[c.Hash] = true }); err != nil {
+			return 0, err
+		}
+	}
+	distance := 0
+	err := walkCommits(repo, head, func(c *object.Commit) {
+		if !excluded[c.Hash] {
+			distance++
+		}
+	})
+	return distance, err
+}
+
+func walkCommits(repo *git.Repository, from plumbing.Hash, visit func(*object.Commit)) error {
+	commits, err := repo.Log(&git.LogOptions{From: from, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return err
+	}
+	return commits.ForEach(func(c *object.Commit) error {
+		visit(c)
+		return nil
+	})
+}
+
+// isDirty tells whether tracked files have local changes, untracked files are ignored like git describe --dirty does
+func isDirty(repo *git.Repository) (bool, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return false, errors.Join(errors.New("Cannot read the worktree"), err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return false, errors.Join(errors.New("Cannot read the worktree status"), err)
+	}
+	for _, file := range status {
+		if file.Worktree != git.Untracked || file.Staging != git.Untracked {
+			return true, nil
+		}
+	}
+	return false, nil
+}
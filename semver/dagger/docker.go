@@ -0,0 +1,67 @@
+package main
+
+import "fmt"
+
+// DockerTags derives the image tags of a release: the exact version plus the floating major, major.minor and latest tags.
+// Prereleases only get the exact tag, and floating tags never move back to an older release than one already tagged.
+func (r *Semver) DockerTags(
+	version string,
+	// Tags already pushed for the image, non-semver ones are ignored
+	// +optional
+	existingTags []string,
+	// Commit SHA to add as a tag
+	// +optional
+	sha string,
+	// Do not add the latest tag
+	// +optional
+	noLatest bool,
+) ([]string, error) {
+	ver, err := r.Parse(version)
+	if err != nil {
+		return nil, err
+	}
+	exact, err := r.Render(version, "oci")
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []string{exact}
+	if ver.Prerelease == "" {
+		var existing []*Version
+		for _, tag := range existingTags {
+			// Image tags are commonly v1.2.3 or 1.2.3
+			parsed := r.tagVersion(tag, "v")
+			if parsed == nil {
+				parsed = r.tagVersion(tag, "")
+			}
+			if parsed != nil && parsed.Prerelease == "" {
+				existing = append(existing, parsed)
+			}
+		}
+
+		// A floating tag only moves when no newer release already shares it
+		isNewest := func(sameLine func(*Version) bool) bool {
+			for _, e := range existing {
+				if sameLine(e) && compareVersions(e, ver) > 0 {
+					return false
+				}
+			}
+			return true
+		}
+
+		if isNewest(func(e *Version) bool { return e.Maj == ver.Maj && e.Min == ver.Min }) {
+			tags = append(tags, fmt.Sprintf("%d.%d", ver.Maj, ver.Min))
+		}
+		if isNewest(func(e *Version) bool { return e.Maj == ver.Maj }) {
+			tags = append(tags, fmt.Sprintf("%d", ver.Maj))
+		}
+		if !noLatest && isNewest(func(e *Version) bool { return true }) {
+			tags = append(tags, "latest")
+		}
+	}
+
+	if sha != "" {
+		tags = append(tags, sha)
+	}
+	return tags, nil
+}
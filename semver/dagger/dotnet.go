@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+import "github.com/antchfx/xmlquery"
+
+var msbuildPropertyPattern = regexp.MustCompile(`\$\((\w+)\)`)
+
+// getVersionFromDotnet reads the version of the shallowest project defining one, nested ones included, the first by path
+// on a tie. Like MSBuild, the nearest Directory.Build.props above the project is imported before it.
+func (r *Semver) getVersionFromDotnet(ctx context.Context, src *Directory) *string {
+	propsFiles := make(map[string]bool)
+	for _, file := range r.findDotnetFiles(ctx, src, "Directory.Build.props") {
+		propsFiles[file] = true
+	}
+	projects := r.findDotnetFiles(ctx, src, "*.csproj")
+	if len(projects) == 0 {
+		// The version may then only be in the root Directory.Build.props
+		projects = []string{""}
+	}
+
+	for _, project := range projects {
+		props := make(map[string]string)
+		for _, file := range []string{nearestMsbuildProps(project, propsFiles), project} {
+			if file == "" {
+				continue
+			}
+			contents, err := src.File(file).Contents(ctx)
+			if err != nil {
+				fmt.Printf("Cannot read %s\n", file)
+				continue
+			}
+			if err := readMsbuildProperties(contents, props); err != nil {
+				fmt.Printf("Cannot parse %s\n", file)
+				fmt.Println(err)
+			}
+		}
+		if version := msbuildVersion(props); version != "" {
+			return &version
+		}
+	}
+
+	return r.getVersionFromNuspec(ctx, src)
+}
+
+// nearestMsbuildProps returns the Directory.Build.props of propsFiles closest above file, empty without any
+func nearestMsbuildProps(file string, propsFiles map[string]bool) string {
+	for dir := path.Dir(file); ; dir = path.Dir(dir) {
+		if propsFile := path.Join(dir, "Directory.Build.props"); propsFiles[propsFile] {
+			return propsFile
+		}
+		if dir == "." || dir == "/" {
+			return ""
+		}
+	}
+}
+
+// findDotnetFiles finds the files matching name at any depth, outside of the build output, the shallowest first
+func (r *Semver) findDotnetFiles(ctx context.Context, src *Directory, name string) []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, pattern := range []string{name, "**/" + name} {
+		matches, err := src.Glob(ctx, pattern)
+		if err != nil {
+			fmt.Printf("Cannot find a %s\n", name)
+			continue
+		}
+		for _, match := range matches {
+			if !seen[match] && !isDotnetOutput(match) {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if depth := strings.Count(files[i], "/") - strings.Count(files[j], "/"); depth != 0 {
+			return depth < 0
+		}
+		return files[i] < files[j]
+	})
+	return files
+}
+
+func (r *Semver) getVersionFromNuspec(ctx context.Context, src *Directory) *string {
+	for _, nuspec := range r.findDotnetFiles(ctx, src, "*.nuspec") {
+		contents, err := src.File(nuspec).Contents(ctx)
+		if err != nil {
+			fmt.Printf("Cannot read %s\n", nuspec)
+			continue
+		}
+		root, err := xmlquery.Parse(strings.NewReader(contents))
+		if err != nil {
+			fmt.Printf("Cannot parse %s\n", nuspec)
+			fmt.Println(err)
+			continue
+		}
+
+		// A $version$ token is only replaced by nuget pack
+		version := innerText(xmlquery.FindOne(root, "/package/metadata/version"))
+		if version == "" || strings.Contains(version, "$") {
+			fmt.Printf("Cannot find a version in %s\n", nuspec)
+			continue
+		}
+		return &version
+	}
+	fmt.Println("Cannot find a .nuspec with a version")
+	return nil
+}
+
+// readMsbuildProperties collects the unconditional properties of every <PropertyGroup>
+func readMsbuildProperties(contents string, props map[string]string) error {
+	root, err := xmlquery.Parse(strings.NewReader(contents))
+	if err != nil {
+		return err
+	}
+	for _, group := range xmlquery.Find(root, "/Project/PropertyGroup") {
+		if group.SelectAttr("Condition") != "" {
+			continue
+		}
+		for prop := group.FirstChild; prop != nil; prop = prop.NextSibling {
+			if prop.Type == xmlquery.ElementNode && prop.SelectAttr("Condition") == "" {
+				props[prop.Data] = expandMsbuildProperties(strings.TrimSpace(prop.InnerText()), props)
+			}
+		}
+	}
+	return nil
+}
+
+func expandMsbuildProperties(value string, props map[string]string) string {
+	return msbuildPropertyPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		return props[ref[2:len(ref)-1]]
+	})
+}
+
+// msbuildVersion follows the MSBuild defaults: PackageVersion, then Version, then VersionPrefix-VersionSuffix
+func msbuildVersion(props map[string]string) string {
+	if version := props["PackageVersion"]; version != "" {
+		return version
+	}
+	if version := props["Version"]; version != "" {
+		return version
+	}
+	version := props["VersionPrefix"]
+	if version != "" && props["VersionSuffix"] != "" {
+		version += "-" + props["VersionSuffix"]
+	}
+	return version
+}
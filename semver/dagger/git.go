@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+import "github.com/go-git/go-billy/v5"
+import "github.com/go-git/go-git/v5"
+import "github.com/go-git/go-git/v5/plumbing"
+import "github.com/go-git/go-git/v5/plumbing/object"
+import "github.com/go-git/go-git/v5/storage/filesystem"
+
+// gitHead describes the commit checked out in a repository
+type gitHead struct {
+	Hash string
+	// Abbreviated like git rev-parse --short does
+	ShortHash string
+	// Empty when HEAD is detached
+	Branch     string
+	CommitTime time.Time
+}
+
+// gitContainer returns a container with git installed and src mounted as its workdir
+func gitContainer(src *Directory) *Container {
+	return dag.Container().From("alpine:latest").
+		WithExec([]string{"apk", "add", "git"}).
+		WithMountedDirectory("/src/", src).
+		WithWorkdir("/src")
+}
+
+// gitSource opens the repository of a source directory on first use and keeps it for the later ones.
+// Only .git is exported into the module runtime, the worktree too when it is asked for. close removes the export.
+type gitSource struct {
+	src      *Directory
+	worktree bool
+	dir      string
+	repo     *git.Repository
+	err      error
+}
+
+func newGitSource(src *Directory, worktree bool) *gitSource {
+	return &gitSource{src: src, worktree: worktree}
+}
+
+// open exports and opens the repository, without any git binary
+func (s *gitSource) open(ctx context.Context) (*git.Repository, error) {
+	if s.repo != nil || s.err != nil {
+		return s.repo, s.err
+	}
+	s.repo, s.err = s.export(ctx)
+	return s.repo, s.err
+}
+
+func (s *gitSource) export(ctx context.Context) (*git.Repository, error) {
+	dir, err := os.MkdirTemp("", "semver-src-")
+	if err != nil {
+		return nil, err
+	}
+	s.dir = dir
+
+	if s.worktree {
+		if _, err := s.src.Export(ctx, dir); err != nil {
+			return nil, errors.Join(errors.New("Cannot export the source directory"), err)
+		}
+	} else if _, err := s.src.Directory(".git").Export(ctx, filepath.Join(dir, ".git")); err != nil {
+		return nil, errors.Join(errors.New("Cannot export the .git directory"), err)
+	}
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, errors.Join(errors.New("Cannot open the git repository"), err)
+	}
+	return repo, nil
+}
+
+func (s *gitSource) close() {
+	if s.dir != "" {
+		os.RemoveAll(s.dir)
+	}
+	s.dir, s.repo, s.err = "", nil, nil
+}
+
+func readGitHead(repo *git.Repository) (*gitHead, error) {
+	ref, err := repo.Head()
+	if err != nil {
+		return nil, errors.Join(errors.New("Cannot resolve HEAD"), err)
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, errors.Join(errors.New("Cannot read the HEAD commit"), err)
+	}
+
+	abbrev, err := abbrevLength(repo, []plumbing.Hash{ref.Hash()})
+	if err != nil {
+		return nil, err
+	}
+	head := &gitHead{
+		Hash:       ref.Hash().String(),
+		ShortHash:  ref.Hash().String()[:abbrev],
+		CommitTime: commit.Committer.When.UTC(),
+	}
+	if ref.Name().IsBranch() {
+		head.Branch = ref.Name().Short()
+	}
+	return head, nil
+}
+
+const fullHashLength = 40
+
+// abbrevLength picks the hash abbreviation length like git does: core.abbrev, or by default one that grows with the
+// number of objects, at least 7. It is then extended until it is unambiguous for every hash.
+func abbrevLength(repo *git.Repository, hashes []plumbing.Hash) (int, error) {
+	length := -1
+	cfg, err := repo.Config()
+	if err != nil {
+		return 0, errors.Join(errors.New("Cannot read the git config"), err)
+	}
+	switch abbrev := cfg.Raw.Section("core").Option("abbrev"); abbrev {
+	case "", "auto":
+	case "no", "false", "off":
+		return fullHashLength, nil
+	default:
+		if n, err := strconv.Atoi(abbrev); err == nil {
+			length = min(max(n, 4), fullHashLength)
+		}
+	}
+
+	fs, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return max(length, 7), nil
+	}
+	if length < 0 {
+		count, err := approximateObjectCount(fs.Filesystem())
+		if err != nil {
+			return 0, err
+		}
+		// 2^bits objects are expected to collide at 2^(bits/2), and a hex digit holds 4 bits
+		length = max((bits.Len(uint(count))+1)/2, 7)
+	}
+
+	if len(hashes) == 0 {
+		return length, nil
+	}
+	// The objects are listed once and sorted, the closest hashes to each one then being its neighbours
+	objects, err := fs.HashesWithPrefix(nil)
+	if err != nil {
+		return 0, err
+	}
+	plumbing.HashesSort(objects)
+	for _, hash := range hashes {
+		i := sort.Search(len(objects), func(i int) bool { return bytes.Compare(objects[i][:], hash[:]) >= 0 })
+		for _, j := range []int{i - 1, i, i + 1} {
+			if j < 0 || j >= len(objects) || objects[j] == hash {
+				continue
+			}
+			length = max(length, min(commonHexPrefix(objects[j], hash)+1, fullHashLength))
+		}
+	}
+	return length, nil
+}
+
+// commonHexPrefix returns how many leading hex digits two hashes share
+func commonHexPrefix(a plumbing.Hash, b plumbing.Hash) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i]>>4 == b[i]>>4 {
+				return 2*i + 1
+			}
+			return 2 * i
+		}
+	}
+	return fullHashLength
+}
+
+// approximateObjectCount counts the packed objects from the fan-out tables of the pack indexes, and the loose objects
+func approximateObjectCount(fs billy.Filesystem) (int, error) {
+	count := 0
+	packs, err := fs.ReadDir("objects/pack")
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	for _, pack := range packs {
+		if !strings.HasSuffix(pack.Name(), ".idx") {
+			continue
+		}
+		idx, err := fs.Open(fs.Join("objects/pack", pack.Name()))
+		if err != nil {
+			return 0, err
+		}
+		// A v2 index has a 4 byte magic, a 4 byte version, then 256 cumulative counts, the last being the total
+		header := make([]byte, 8+256*4)
+		_, err = io.ReadFull(idx, header)
+		idx.Close()
+		if err != nil {
+			return 0, errors.Join(errors.New(fmt.Sprintf("Cannot read the pack index %s", pack.Name())), err)
+		}
+		count += int(binary.BigEndian.Uint32(header[len(header)-4:]))
+	}
+
+	dirs, err := fs.ReadDir("objects")
+	if err != nil {
+		return 0, err
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() || len(dir.Name()) != 2 {
+			continue
+		}
+		loose, err := fs.ReadDir(fs.Join("objects", dir.Name()))
+		if err != nil {
+			return 0, err
+		}
+		count += len(loose)
+	}
+	return count, nil
+}
+
+// gitTag is a tag and the commit it points to, peeled when the tag is annotated
+type gitTag struct {
+	Name   string
+	Commit plumbing.Hash
+}
+
+// gitTags lists the tags pointing to commits reachable from rev, every tag when rev is empty
+func gitTags(repo *git.Repository, rev string) ([]gitTag, error) {
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, errors.Join(errors.New("Cannot list the tags"), err)
+	}
+	var tags []gitTag
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		hash := ref.Hash()
+		if tag, err := repo.TagObject(hash); err == nil {
+			commit, err := tag.Commit()
+			if err != nil {
+				// Tags of anything but a commit hold no version
+				return nil
+			}
+			hash = commit.Hash
+		}
+		tags = append(tags, gitTag{Name: ref.Name().Short(), Commit: hash})
+		return nil
+	})
+	if err != nil || rev == "" {
+		return tags, err
+	}
+
+	from, err := resolveRevision(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+	reachable := make(map[plumbing.Hash]bool)
+	if err := walkCommits(repo, from, func(c *object.Commit) { reachable[c.Hash] = true }); err != nil {
+		return nil, err
+	}
+	var merged []gitTag
+	for _, tag := range tags {
+		if reachable[tag.Commit] {
+			merged = append(merged, tag)
+		}
+	}
+	return merged, nil
+}
+
+func tagNames(tags []gitTag) []string {
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// resolveRevision resolves a branch, tag, HEAD or hash to the commit it designates
+func resolveRevision(repo *git.Repository, rev string) (plumbing.Hash, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return plumbing.ZeroHash, errors.Join(errors.New(fmt.Sprintf("Cannot resolve %s", rev)), err)
+	}
+	return *hash, nil
+}
+
+// latestTag returns the tag with the highest precedence and the version it holds.
+// Tags that are not a prefixed semver are skipped.
+func (r *Semver) latestTag(tags []string, prefix string, includePrerelease bool) (string, *Version) {
+	var bestTag string
+	var best *Version
+	for _, tag := range tags {
+		ver, ok := strings.CutPrefix(tag, prefix)
+		if !ok {
+			continue
+		}
+		parsed, err := r.Parse(ver)
+		if err != nil {
+			continue
+		}
+		if parsed.Prerelease != "" && !includePrerelease {
+			continue
+		}
+		if best == nil || compareVersions(parsed, best) > 0 {
+			bestTag, best = tag, parsed
+		}
+	}
+	return bestTag, best
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var gradleVersionPattern = regexp.MustCompile(`^(?:project\.)?version\s*(?:=\s*|\s+)(?P<value>.+?)\s*;?$`)
+var gradleVariablePattern = regexp.MustCompile(`^(?:(?:project\.|rootProject\.)?ext\.|def\s+|val\s+|var\s+|(?:\w+\s+)?extra\[["'])?(?P<name>\w+)(?:["']\])?\s*(?::\s*\w+\s*)?=\s*(?P<value>["'].*["'])\s*;?$`)
+var gradlePropertyCallPattern = regexp.MustCompile(`^(?:(?:root)?[pP]roject\.)?(?:findProperty|property)\(\s*["'](?P<name>[\w.]+)["']\s*\)(?:\s+as\s+String|\.toString\(\)|!!)?$`)
+var gradleReferencePattern = regexp.MustCompile(`^(?:(?:root)?[pP]roject\.)?(?:ext\.)?(?P<name>\w+)$`)
+var gradleInterpolationPattern = regexp.MustCompile(`\$\{(?P<expr>[^{}]*)\}|\$(?P<name>\w+)`)
+var gradleBlockCommentPattern = regexp.MustCompile(`(?s)/\*.*?\*/`)
+var gradleLineCommentPattern = regexp.MustCompile(`(?m)(^|\s)//.*$`)
+var gradleStringPattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`)
+
+func (r *Semver) getVersionFromGradle(ctx context.Context, src *Directory) *string {
+	props := make(map[string]string)
+	if contents, err := src.File("gradle.properties").Contents(ctx); err == nil {
+		props = parseJavaProperties(contents)
+	}
+
+	for _, script := range []string{"build.gradle.kts", "build.gradle"} {
+		contents, err := src.File(script).Contents(ctx)
+		if err != nil {
+			continue
+		}
+		if version, ok := parseGradleScript(contents, props); ok {
+			return &version
+		}
+		fmt.Printf("Cannot find a version in %s\n", script)
+	}
+
+	if version, ok := props["version"]; ok && version != "" {
+		return &version
+	}
+	fmt.Println("Cannot find a Gradle version")
+	return nil
+}
+
+func parseJavaProperties(contents string) map[string]string {
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			continue
+		}
+		props[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+	}
+	return props
+}
+
+// parseGradleScript finds the project version of a Groovy or Kotlin build script.
+// Assignments at the top level win over allprojects, which win over subprojects blocks.
+func parseGradleScript(contents string, props map[string]string) (string, bool) {
+	vars := make(map[string]string)
+	for key, value := range props {
+		vars[key] = value
+	}
+
+	candidates := make(map[string]string)
+	var blocks []string
+	scanner := bufio.NewScanner(strings.NewReader(stripGradleComments(contents)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		scope := strings.Join(blocks, "/")
+		if scope == "" || scope == "allprojects" || scope == "subprojects" || scope == "ext" {
+			if groups := gradleVariablePattern.FindStringSubmatch(line); groups != nil {
+				name := groups[gradleVariablePattern.SubexpIndex("name")]
+				if name != "version" {
+					if value, ok := resolveGradleValue(groups[gradleVariablePattern.SubexpIndex("value")], vars); ok {
+						vars[name] = value
+					}
+				}
+			}
+		}
+		if groups := gradleVersionPattern.FindStringSubmatch(line); groups != nil {
+			if _, seen := candidates[scope]; !seen {
+				candidates[scope] = groups[gradleVersionPattern.SubexpIndex("value")]
+			}
+		}
+
+		blocks = trackGradleBlocks(blocks, line)
+	}
+
+	for _, scope := range []string{"", "allprojects", "subprojects"} {
+		if expr, ok := candidates[scope]; ok {
+			if version, ok := resolveGradleValue(expr, vars); ok {
+				return version, true
+			}
+			fmt.Printf("Cannot resolve Gradle version %s\n", expr)
+		}
+	}
+	return "", false
+}
+
+// trackGradleBlocks pushes the name of every block opened on the line and pops the closed ones
+func trackGradleBlocks(blocks []string, line string) []string {
+	line = stripGradleStrings(line)
+	for i, c := range line {
+		switch c {
+		case '{':
+			fields := strings.Fields(strings.TrimRight(line[:i], " ({"))
+			name := ""
+			if len(fields) > 0 {
+				name = fields[len(fields)-1]
+			}
+			blocks = append(blocks, name)
+		case '}':
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+		}
+	}
+	return blocks
+}
+
+func resolveGradleValue(expr string, vars map[string]string) (string, bool) {
+	expr = strings.TrimSpace(expr)
+	if len(expr) >= 2 && (expr[0] == '\'' && expr[len(expr)-1] == '\'') {
+		return expr[1 : len(expr)-1], true
+	}
+	if len(expr) >= 2 && (expr[0] == '"' && expr[len(expr)-1] == '"') {
+		// Any interpolation left unresolved means the value is not known
+		resolved := true
+		value := gradleInterpolationPattern.ReplaceAllStringFunc(expr[1:len(expr)-1], func(ref string) string {
+			groups := gradleInterpolationPattern.FindStringSubmatch(ref)
+			inner := groups[gradleInterpolationPattern.SubexpIndex("expr")] + groups[gradleInterpolationPattern.SubexpIndex("name")]
+			if value, ok := resolveGradleValue(inner, vars); ok {
+				return value
+			}
+			resolved = false
+			return ref
+		})
+		return value, resolved && !strings.Contains(value, "${")
+	}
+	if groups := gradlePropertyCallPattern.FindStringSubmatch(expr); groups != nil {
+		value, ok := vars[groups[gradlePropertyCallPattern.SubexpIndex("name")]]
+		return value, ok
+	}
+	if groups := gradleReferencePattern.FindStringSubmatch(expr); groups != nil {
+		value, ok := vars[groups[gradleReferencePattern.SubexpIndex("name")]]
+		return value, ok
+	}
+	return "", false
+}
+
+func stripGradleComments(contents string) string {
+	contents = gradleBlockCommentPattern.ReplaceAllString(contents, "")
+	return gradleLineCommentPattern.ReplaceAllString(contents, "$1")
+}
+
+func stripGradleStrings(line string) string {
+	return gradleStringPattern.ReplaceAllString(line, `""`)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+import "gopkg.in/yaml.v3"
+
+// ChartVersion holds the two versions of a Helm chart
+type ChartVersion struct {
+	Name string
+	// Version of the chart itself
+	Version string
+	// Version of the application the chart deploys
+	AppVersion string
+}
+
+func (m *ChartVersion) Json() (string, error) {
+	if m == nil {
+		return "", errors.New("cannot get ChartVersion")
+	}
+	b, err := json.Marshal(*m)
+	if err != nil {
+		fmt.Println(err)
+		return "", err
+	}
+	return string(b), nil
+}
+
+// DetectChartVersion reads both the chart version and the appVersion of Chart.yaml
+func (r *Semver) DetectChartVersion(ctx context.Context,
+	// Path to the chart directory
+	src *Directory,
+) (*ChartVersion, error) {
+	contents, err := src.File("Chart.yaml").Contents(ctx)
+	if err != nil {
+		return nil, errors.Join(errors.New("Cannot find a Chart.yaml"), err)
+	}
+	var chart struct {
+		Name       string `yaml:"name"`
+		Version    string `yaml:"version"`
+		AppVersion string `yaml:"appVersion"`
+	}
+	if err := yaml.Unmarshal([]byte(contents), &chart); err != nil {
+		return nil, errors.Join(errors.New("Cannot parse Chart.yaml"), err)
+	}
+	return &ChartVersion{
+		Name:       chart.Name,
+		Version:    chart.Version,
+		AppVersion: chart.AppVersion,
+	}, nil
+}
+
+func (r *Semver) getVersionFromChartYaml(ctx context.Context, src *Directory, field string) *string {
+	chart, err := r.DetectChartVersion(ctx, src)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+
+	version := chart.Version
+	if field == "appVersion" {
+		version = chart.AppVersion
+	}
+	if version == "" {
+		fmt.Printf("Cannot find %s in Chart.yaml\n", field)
+		return nil
+	}
+	return &version
+}
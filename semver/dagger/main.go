@@ -10,14 +10,27 @@ import (
 	"github.com/ohler55/ojg/jp"
 	"github.com/ohler55/ojg/oj"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	texttemplate "text/template"
+	"time"
 )
-import "github.com/antchfx/xmlquery"
 import "gobn.github.io/coalesce"
 
 type Semver struct{}
 
+var semverPattern = regexp.MustCompile("^(?P<major>0|[1-9]\\d*)\\.(?P<minor>0|[1-9]\\d*)\\.(?P<patch>0|[1-9]\\d*)(?:-(?P<prerelease>(?:0|[1-9]\\d*|\\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\\.(?:0|[1-9]\\d*|\\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\\.[0-9a-zA-Z-]+)*))?$")
+
+// ModuleVersion is the version of a single module of a multi-module project
+type ModuleVersion struct {
+	// Path to the module manifest, relative to the source directory
+	Path    string
+	Name    string
+	Version string
+}
+
+// Version is a parsed SemVer, with chainable operations
 type Version struct {
 	Maj        int
 	Min        int
@@ -39,6 +52,122 @@ func (m *Version) Json() (string, error) {
 	return string(b), nil
 }
 
+// BumpMajor returns the next major version. A prerelease of X.0.0 is released as X.0.0
+func (m *Version) BumpMajor() *Version {
+	next := &Version{Maj: m.Maj + 1}
+	if m.Prerelease != "" && m.Min == 0 && m.Patch == 0 {
+		next.Maj = m.Maj
+	}
+	return next
+}
+
+// BumpMinor returns the next minor version. A prerelease of X.Y.0 is released as X.Y.0
+func (m *Version) BumpMinor() *Version {
+	next := &Version{Maj: m.Maj, Min: m.Min + 1}
+	if m.Prerelease != "" && m.Patch == 0 {
+		next.Min = m.Min
+	}
+	return next
+}
+
+// BumpPatch returns the next patch version. A prerelease of X.Y.Z is released as X.Y.Z
+func (m *Version) BumpPatch() *Version {
+	next := &Version{Maj: m.Maj, Min: m.Min, Patch: m.Patch + 1}
+	if m.Prerelease != "" {
+		next.Patch = m.Patch
+	}
+	return next
+}
+
+// BumpPrerelease increments the prerelease counter (rc.1 -> rc.2).
+// A release gets its patch bumped and starts a new <identifier>.1 prerelease.
+func (m *Version) BumpPrerelease(
+	// Prerelease identifier, e.g. alpha, beta, rc. Defaults to the current one
+	// +optional
+	identifier string,
+) (*Version, error) {
+	next := &Version{Maj: m.Maj, Min: m.Min, Patch: m.Patch}
+
+	switch {
+	case m.Prerelease == "":
+		if identifier == "" {
+			return nil, errors.New(fmt.Sprintf("Prerelease identifier is required to bump release %s", m.String()))
+		}
+		next.Patch++
+		next.Prerelease = identifier + ".1"
+	case identifier == "" || m.Prerelease == identifier || strings.HasPrefix(m.Prerelease, identifier+"."):
+		parts := strings.Split(m.Prerelease, ".")
+		last := parts[len(parts)-1]
+		if isNumeric(last) {
+			counter, err := strconv.Atoi(last)
+			if err != nil {
+				return nil, err
+			}
+			parts[len(parts)-1] = strconv.Itoa(counter + 1)
+		} else {
+			parts = append(parts, "1")
+		}
+		next.Prerelease = strings.Join(parts, ".")
+	default:
+		next.Prerelease = identifier + ".1"
+	}
+
+	if _, err := (&Semver{}).Parse(next.String()); err != nil {
+		return nil, errors.Join(errors.New(fmt.Sprintf("Invalid prerelease identifier: %s", identifier)), err)
+	}
+	if compareVersions(next, m) <= 0 {
+		return nil, errors.New(fmt.Sprintf("Bumping %s to %s would not increase its precedence", m.String(), next.String()))
+	}
+	return next, nil
+}
+
+// String formats the version, e.g. 1.2.3-rc.1+build.5
+func (m *Version) String() string {
+	return (&Semver{}).Build(m.Maj, m.Min, m.Patch, m.Prerelease, m.Build)
+}
+
+// WithPrerelease returns a copy of the version with the prerelease replaced, an empty one makes it a release
+func (m *Version) WithPrerelease(
+	// Dot-separated prerelease identifiers, e.g. rc.1
+	prerelease string,
+) (*Version, error) {
+	next := *m
+	next.Prerelease = prerelease
+	if !semverPattern.MatchString(next.String()) {
+		return nil, errors.New(fmt.Sprintf("Invalid prerelease: %s", prerelease))
+	}
+	return &next, nil
+}
+
+// WithBuild returns a copy of the version with the build metadata replaced, an empty one removes it
+func (m *Version) WithBuild(
+	// Dot-separated build identifiers, e.g. 20240301.abc1234
+	build string,
+) (*Version, error) {
+	next := *m
+	next.Build = build
+	if !semverPattern.MatchString(next.String()) {
+		return nil, errors.New(fmt.Sprintf("Invalid build metadata: %s", build))
+	}
+	return &next, nil
+}
+
+// Compare returns -1, 0 or 1 when the version has a lower, the same or a higher precedence than other.
+// Build metadata is ignored.
+func (m *Version) Compare(other *Version) int {
+	return compareVersions(m, other)
+}
+
+// IsPrerelease tells whether the version has a prerelease
+func (m *Version) IsPrerelease() bool {
+	return m.Prerelease != ""
+}
+
+// IsStable tells whether the version is a release with a stable public API, that is 1.0.0 or above without a prerelease
+func (m *Version) IsStable() bool {
+	return m.Maj > 0 && m.Prerelease == ""
+}
+
 func (m *Semver) GetFull(ctx context.Context,
 	// Path to the source directory
 	src *Directory,
@@ -50,22 +179,116 @@ func (m *Semver) GetFull(ctx context.Context,
 	// Build version
 	// +optional
 	build string,
+
+	// Chart.yaml field that drives the version: version (the chart) or appVersion (the deployed image)
+	// +optional
+	// +default="version"
+	chartField string,
+
+	// Python module holding a __version__ assignment, e.g. src/pkg/__init__.py
+	// +optional
+	pythonVersionFile string,
+
+	// Where the build timestamp comes from: now, commit (the committer date of HEAD) or fixed (the timestamp argument)
+	// +optional
+	// +default="now"
+	timestampSource string,
+
+	// Fixed build timestamp, as seconds since the epoch (e.g. $SOURCE_DATE_EPOCH) or RFC 3339
+	// +optional
+	timestamp string,
+
+	// strftime-like build timestamp format
+	// +optional
+	// +default="%Y%m%dT%H%M%S"
+	timestampFormat string,
+
+	// Go template of the build metadata, over .Timestamp .Sha .ShortSha .Branch and .CiRun
+	// +optional
+	// +default="{{.Timestamp}}-{{.ShortSha}}"
+	buildTemplate string,
+
+	// CI run identifier, e.g. $GITHUB_RUN_NUMBER, for the .CiRun build template field
+	// +optional
+	ciRun string,
+
+	// Derive the version from the closest git tag and the commits since, like git describe.
+	// The build metadata is then the commit and dirty state, it cannot be combined with a version, build or policy.
+	// +optional
+	describe bool,
+
+	// Prefix of the release tags, e.g. v, api/v, release-
+	// +optional
+	// +default="v"
+	tagPrefix string,
+
+	// Prerelease channels by branch as pattern=channel rules, first match wins, e.g. main= develop=beta feature/*=alpha.{branch}.
+	// An empty channel is a release, {branch} is the sanitized branch name, the channel gets a .N counter.
+	// +optional
+	policy []string,
+
+	// Branch to apply the policy for, defaults to the checked out branch. Required when HEAD is detached, as in most CI checkouts.
+	// +optional
+	branch string,
 ) (string, error) {
 
 	var err error
 
+	// Opened at most once, and only when a git input is needed. Describing checks the worktree for local changes.
+	gitSrc := newGitSource(src, describe)
+	defer gitSrc.close()
+
+	if describe {
+		if version != "" || build != "" || len(policy) > 0 {
+			return "", errors.New("A described version cannot be combined with a version, build or policy")
+		}
+		repo, err := gitSrc.open(ctx)
+		if err != nil {
+			return "", err
+		}
+		devVer, err := m.describeVersion(repo, tagPrefix)
+		if err != nil {
+			return "", err
+		}
+		if !m.Validate(devVer) {
+			return "", errors.New(fmt.Sprintf("Invalid SemVer: %s", devVer))
+		}
+		return devVer, nil
+	}
+
 	if build == "" {
-		build, err = m.GetBuild(ctx, src, false, false)
+		build, err = m.buildMetadata(ctx, gitSrc, buildTemplate, ciRun, timestampSource, timestamp, timestampFormat)
 		if err != nil {
 			return "", err
 		}
 	}
 
 	if version == "" {
-		version, err = m.DetectVersion(ctx, src)
+		version, err = m.detectVersion(ctx, src, gitSrc, false, tagPrefix, false, pythonVersionFile, chartField)
+		if err != nil {
+			return "", err
+		}
+		// Manifests commonly hold loose versions, such as the Maven 1.0-SNAPSHOT
+		if !semverPattern.MatchString(version) {
+			coerced, err := m.Coerce(version)
+			if err != nil {
+				return "", err
+			}
+			fmt.Printf("Coerced the detected version %s to %s: %s\n", version, coerced.Version, strings.Join(coerced.Changes, ", "))
+			version = coerced.Version
+		}
+	}
+
+	if len(policy) > 0 {
+		ver, err := m.Parse(version)
+		if err != nil {
+			return "", err
+		}
+		ver, err = m.applyPrereleasePolicy(ctx, gitSrc, ver, policy, branch, tagPrefix)
 		if err != nil {
 			return "", err
 		}
+		version = ver.String()
 	}
 
 	fullVer := m.ConcatVersion(version, build)
@@ -76,39 +299,227 @@ func (m *Semver) GetFull(ctx context.Context,
 	}
 }
 
+// buildInfo holds the values a build metadata template can refer to
+type buildInfo struct {
+	Timestamp string
+	Sha       string
+	ShortSha  string
+	// Empty when HEAD is detached
+	Branch string
+	CiRun  string
+}
+
+var buildMetadataPattern = regexp.MustCompile(`^[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*$`)
+
 func (m *Semver) GetBuild(ctx context.Context,
 	// Path to the source directory
 	src *Directory,
+
+	// Go template of the build metadata, over .Timestamp .Sha .ShortSha .Branch and .CiRun, e.g. {{.Timestamp}}.{{.ShortSha}}.{{.Branch}}.{{.CiRun}}
+	// +optional
+	// +default="{{.Timestamp}}-{{.ShortSha}}"
+	template string,
+
+	// CI run identifier, e.g. $GITHUB_RUN_NUMBER, for the .CiRun template field
+	// +optional
+	ciRun string,
+
+	// Where the timestamp comes from: now, commit (the committer date of HEAD) or fixed (the timestamp argument)
+	// +optional
+	// +default="now"
+	timestampSource string,
+
+	// Fixed timestamp, as seconds since the epoch (e.g. $SOURCE_DATE_EPOCH) or RFC 3339
+	// +optional
+	timestamp string,
+
+	// strftime-like timestamp format
+	// +optional
+	// +default="%Y%m%dT%H%M%S"
+	timestampFormat string,
+) (string, error) {
+	gitSrc := newGitSource(src, false)
+	defer gitSrc.close()
+	return m.buildMetadata(ctx, gitSrc, template, ciRun, timestampSource, timestamp, timestampFormat)
+}
+
+func (m *Semver) buildMetadata(ctx context.Context, gitSrc *gitSource, template string, ciRun string, timestampSource string, timestamp string, timestampFormat string) (string, error) {
+	if template == "" {
+		template = "{{.Timestamp}}-{{.ShortSha}}"
+	}
+	tmpl, err := texttemplate.New("build").Parse(template)
+	if err != nil {
+		return "", errors.Join(errors.New(fmt.Sprintf("Invalid build template: %s", template)), err)
+	}
+
+	info := buildInfo{CiRun: ciRun}
+	usesTs := strings.Contains(template, ".Timestamp")
+	var head *gitHead
+	if strings.Contains(template, ".Sha") || strings.Contains(template, ".ShortSha") || strings.Contains(template, ".Branch") ||
+		(usesTs && timestampSource == "commit") {
+		repo, err := gitSrc.open(ctx)
+		if err != nil {
+			return "", err
+		}
+		head, err = readGitHead(repo)
+		if err != nil {
+			return "", err
+		}
+		info.Sha, info.ShortSha, info.Branch = head.Hash, head.ShortHash, head.Branch
+	}
+	if usesTs {
+		ts, err := buildTimestamp(timestampSource, timestamp, head)
+		if err != nil {
+			return "", err
+		}
+		if timestampFormat == "" {
+			timestampFormat = "%Y%m%dT%H%M%S"
+		}
+		info.Timestamp = strftime(ts, timestampFormat)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, info); err != nil {
+		return "", errors.Join(errors.New(fmt.Sprintf("Cannot render the build template: %s", template)), err)
+	}
+	build := sanitizeBuildMetadata(out.String())
+	if build != "" && !buildMetadataPattern.MatchString(build) {
+		return "", errors.New(fmt.Sprintf("Invalid build metadata: %s", build))
+	}
+	return build, nil
+}
+
+// sanitizeBuildMetadata replaces the characters build metadata cannot hold with dashes and drops the empty identifiers,
+// so that a missing field such as the branch of a detached HEAD leaves no dangling dot
+func sanitizeBuildMetadata(build string) string {
+	var ids []string
+	for _, id := range strings.Split(build, ".") {
+		// Unlike prerelease identifiers, numeric ones may have leading zeros
+		if id = strings.Trim(identifierInvalidChars.ReplaceAllString(id, "-"), "-"); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return strings.Join(ids, ".")
+}
+
+// buildTimestamp picks the build time, in UTC. A timestamp given without a source is a fixed one.
+func buildTimestamp(source string, timestamp string, head *gitHead) (time.Time, error) {
+	if timestamp != "" && (source == "" || source == "now") {
+		source = "fixed"
+	}
+	switch source {
+	case "", "now":
+		return time.Now().UTC(), nil
+	case "commit":
+		return head.CommitTime, nil
+	case "fixed":
+		if epoch, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+			return time.Unix(epoch, 0).UTC(), nil
+		}
+		ts, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return time.Time{}, errors.Join(errors.New(fmt.Sprintf("Invalid timestamp: %s", timestamp)), err)
+		}
+		return ts.UTC(), nil
+	default:
+		return time.Time{}, errors.New(fmt.Sprintf("Unknown timestamp source: %s", source))
+	}
+}
+
+// strftime formats the time with the %Y %y %m %d %H %M %S %j %s and %% directives of date(1)
+func strftime(t time.Time, format string) string {
+	var out strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			out.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'Y':
+			out.WriteString(fmt.Sprintf("%04d", t.Year()))
+		case 'y':
+			out.WriteString(fmt.Sprintf("%02d", t.Year()%100))
+		case 'm':
+			out.WriteString(fmt.Sprintf("%02d", int(t.Month())))
+		case 'd':
+			out.WriteString(fmt.Sprintf("%02d", t.Day()))
+		case 'H':
+			out.WriteString(fmt.Sprintf("%02d", t.Hour()))
+		case 'M':
+			out.WriteString(fmt.Sprintf("%02d", t.Minute()))
+		case 'S':
+			out.WriteString(fmt.Sprintf("%02d", t.Second()))
+		case 'j':
+			out.WriteString(fmt.Sprintf("%03d", t.YearDay()))
+		case 's':
+			out.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case '%':
+			out.WriteByte('%')
+		default:
+			out.WriteByte('%')
+			out.WriteByte(format[i])
+		}
+	}
+	return out.String()
+}
+
+func (r *Semver) DetectVersion(ctx context.Context,
+	// Path to the source directory
+	src *Directory,
+
+	// Use the highest semver git tag instead of the manifests
+	// +optional
+	fromGitTags bool,
+
+	// Prefix of the release tags, e.g. v, api/v, release-
+	// +optional
+	// +default="v"
+	tagPrefix string,
+
+	// Consider prerelease tags too
+	// +optional
+	includePrerelease bool,
+
+	// Python module holding a __version__ assignment, e.g. src/pkg/__init__.py
 	// +optional
-	noTs bool,
+	pythonVersionFile string,
+
+	// Chart.yaml field to use: version (the chart) or appVersion (the deployed image)
 	// +optional
-	noCommit bool,
+	// +default="version"
+	chartField string,
 ) (string, error) {
-	if noCommit && noTs {
-		return "", nil
-	}
-	return dag.Container().From("alpine:latest").
-		WithExec([]string{"apk", "add", "git"}).
-		WithMountedDirectory("/src/", src).
-		WithWorkdir("/src").
-		WithEnvVariable("NO_TS", fmt.Sprintf("%t", noTs)).
-		WithEnvVariable("NO_COMMIT", fmt.Sprintf("%t", noCommit)).
-		WithExec([]string{"sh", "-c", `
-			parts=""
-			if [ "${NO_TS}" = "false" ] ; then ts=$(TZ=UTC date '+%Y%m%dT%H%M%S'); parts="${parts} ${ts} " ; fi
-			if [ "${NO_COMMIT}" = "false" ] ; then commit=$(git rev-parse --short HEAD); parts="${parts} ${commit} " ; fi
-			
-			for part in ${parts} ; do ver="${ver:+${ver}-}${part}"; done
-			echo "${ver}" | tr -d $'\n' >>/tmp/BUILD
-		`}).File("/tmp/BUILD").
-		Contents(ctx)
-}
-
-func (r *Semver) DetectVersion(ctx context.Context, src *Directory) (string, error) {
-	version := coalesce.String(
-		r.getVersionFromPomXml(ctx, src),
-		r.getVersionFromPackageJson(ctx, src),
-	)
+	gitSrc := newGitSource(src, false)
+	defer gitSrc.close()
+	return r.detectVersion(ctx, src, gitSrc, fromGitTags, tagPrefix, includePrerelease, pythonVersionFile, chartField)
+}
+
+func (r *Semver) detectVersion(ctx context.Context, src *Directory, gitSrc *gitSource, fromGitTags bool, tagPrefix string, includePrerelease bool, pythonVersionFile string, chartField string) (string, error) {
+	if chartField == "" {
+		chartField = "version"
+	}
+	if chartField != "version" && chartField != "appVersion" {
+		return "", errors.New(fmt.Sprintf("Unknown Chart.yaml field: %s", chartField))
+	}
+
+	var version *string
+	if !fromGitTags {
+		version = coalesce.String(
+			r.getVersionFromPomXml(ctx, src),
+			r.getVersionFromPackageJson(ctx, src),
+			r.getVersionFromGradle(ctx, src),
+			r.getVersionFromPython(ctx, src, pythonVersionFile),
+			r.getVersionFromCargo(ctx, src),
+			r.getVersionFromChartYaml(ctx, src, chartField),
+			r.getVersionFromDotnet(ctx, src),
+		)
+	}
+
+	// Git tags are the fallback for repositories without a manifest
+	if version == nil {
+		version = r.getVersionFromGitTags(ctx, gitSrc, tagPrefix, includePrerelease)
+	}
 
 	if version != nil {
 		return *version, nil
@@ -117,22 +528,25 @@ func (r *Semver) DetectVersion(ctx context.Context, src *Directory) (string, err
 	}
 }
 
-func (r *Semver) getVersionFromPomXml(ctx context.Context, src *Directory) *string {
-	contents, err := src.File("pom.xml").Contents(ctx)
-	if err != nil {
-		fmt.Println("Cannot find a pom.xml")
-		return nil
+// DetectModuleVersions lists the version of every module of a multi-module project
+func (r *Semver) DetectModuleVersions(ctx context.Context,
+	// Path to the source directory
+	src *Directory,
+) ([]*ModuleVersion, error) {
+	readFile := func(p string) (string, error) {
+		return src.File(p).Contents(ctx)
 	}
-	root, err := xmlquery.Parse(strings.NewReader(contents))
-	if err != nil {
-		fmt.Println("Cannot parse pom.xml")
-		fmt.Println(err)
-		return nil
+	glob := func(pattern string) ([]string, error) {
+		return src.Glob(ctx, pattern)
 	}
 
-	version := xmlquery.FindOne(root, "//project/version")
-	str := version.InnerText()
-	return &str
+	if _, err := readFile("pom.xml"); err == nil {
+		return walkPomModules(readFile, "pom.xml", r.getMavenConfigProperties(ctx, src), nil)
+	}
+	if contents, err := readFile("Cargo.toml"); err == nil {
+		return cargoWorkspaceVersions(contents, readFile, glob)
+	}
+	return nil, errors.New("Cannot detect modules")
 }
 
 func (r *Semver) getVersionFromPackageJson(ctx context.Context, src *Directory) *string {
@@ -159,6 +573,31 @@ func (r *Semver) getVersionFromPackageJson(ctx context.Context, src *Directory)
 	return &ver
 }
 
+func (r *Semver) getVersionFromGitTags(ctx context.Context, gitSrc *gitSource, prefix string, includePrerelease bool) *string {
+	repo, err := gitSrc.open(ctx)
+	if err != nil {
+		fmt.Println("Cannot open the git repository")
+		fmt.Println(err)
+		return nil
+	}
+	merged, err := gitTags(repo, "HEAD")
+	if err != nil {
+		fmt.Println("Cannot list git tags")
+		fmt.Println(err)
+		return nil
+	}
+
+	tag, version := r.latestTag(tagNames(merged), prefix, includePrerelease)
+	if version == nil {
+		fmt.Printf("Cannot find a semver git tag with prefix '%s'\n", prefix)
+		return nil
+	}
+
+	fmt.Printf("Found version in git tag %s\n", tag)
+	ver := version.String()
+	return &ver
+}
+
 func (r *Semver) Validate(ver string) bool {
 	parsed, err := r.Parse(ver)
 
@@ -171,7 +610,14 @@ func (r *Semver) Validate(ver string) bool {
 	return parsed != nil
 }
 
+// ConcatVersion appends the build metadata to the version, after the build metadata it may already have
 func (r *Semver) ConcatVersion(version string, build string) string {
+	if build == "" {
+		return version
+	}
+	if strings.Contains(version, "+") {
+		return fmt.Sprintf("%s.%s", version, build)
+	}
 	return fmt.Sprintf("%s+%s", version, build)
 }
 
@@ -193,8 +639,7 @@ func (r *Semver) Build(
 }
 
 func (r *Semver) Parse(ver string) (*Version, error) {
-	pattern := regexp.MustCompile("^(?P<major>0|[1-9]\\d*)\\.(?P<minor>0|[1-9]\\d*)\\.(?P<patch>0|[1-9]\\d*)(?:-(?P<prerelease>(?:0|[1-9]\\d*|\\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\\.(?:0|[1-9]\\d*|\\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\\.[0-9a-zA-Z-]+)*))?$")
-	groups := extractGroups(pattern, ver)
+	groups := extractGroups(semverPattern, ver)
 	if len(groups) == 0 {
 		return nil, errors.New(fmt.Sprintf("Cannot parse SemVer in %s", ver))
 	}
@@ -226,6 +671,176 @@ func (r *Semver) Parse(ver string) (*Version, error) {
 	}, nil
 }
 
+func (r *Semver) BumpMajor(ver string) (string, error) {
+	parsed, err := r.Parse(ver)
+	if err != nil {
+		return "", err
+	}
+	return parsed.BumpMajor().String(), nil
+}
+
+func (r *Semver) BumpMinor(ver string) (string, error) {
+	parsed, err := r.Parse(ver)
+	if err != nil {
+		return "", err
+	}
+	return parsed.BumpMinor().String(), nil
+}
+
+func (r *Semver) BumpPatch(ver string) (string, error) {
+	parsed, err := r.Parse(ver)
+	if err != nil {
+		return "", err
+	}
+	return parsed.BumpPatch().String(), nil
+}
+
+func (r *Semver) BumpPrerelease(
+	ver string,
+	// Prerelease identifier, e.g. alpha, beta, rc. Defaults to the current one
+	// +optional
+	identifier string,
+) (string, error) {
+	parsed, err := r.Parse(ver)
+	if err != nil {
+		return "", err
+	}
+	next, err := parsed.BumpPrerelease(identifier)
+	if err != nil {
+		return "", err
+	}
+	return next.String(), nil
+}
+
+// Compare returns -1, 0 or 1 when a has lower, equal or higher precedence than b.
+// Build metadata is ignored, as per https://semver.org/#spec-item-11
+func (r *Semver) Compare(a string, b string) (int, error) {
+	va, err := r.Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := r.Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	return compareVersions(va, vb), nil
+}
+
+// Max returns the version with the highest precedence
+func (r *Semver) Max(versions []string) (string, error) {
+	sorted, err := r.Sort(versions)
+	if err != nil {
+		return "", err
+	}
+	if len(sorted) == 0 {
+		return "", errors.New("No versions to compare")
+	}
+	return sorted[len(sorted)-1], nil
+}
+
+// Sort orders versions by ascending precedence. Versions of equal precedence keep their input order.
+func (r *Semver) Sort(versions []string) ([]string, error) {
+	parsed := make([]*Version, len(versions))
+	for i, ver := range versions {
+		v, err := r.Parse(ver)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = v
+	}
+
+	idx := make([]int, len(versions))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return compareVersions(parsed[idx[i]], parsed[idx[j]]) < 0
+	})
+
+	sorted := make([]string, len(versions))
+	for i, j := range idx {
+		sorted[i] = versions[j]
+	}
+	return sorted, nil
+}
+
+func compareVersions(a *Version, b *Version) int {
+	if c := compareInts(a.Maj, b.Maj); c != 0 {
+		return c
+	}
+	if c := compareInts(a.Min, b.Min); c != 0 {
+		return c
+	}
+	if c := compareInts(a.Patch, b.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(a.Prerelease, b.Prerelease)
+}
+
+func comparePrerelease(a string, b string) int {
+	// A pre-release version has lower precedence than the associated normal version
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareIdentifiers(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(as), len(bs))
+}
+
+func compareIdentifiers(a string, b string) int {
+	aNum := isNumeric(a)
+	bNum := isNumeric(b)
+	switch {
+	case aNum && bNum:
+		// No leading zeroes are allowed, so a longer number is always bigger
+		if c := compareInts(len(a), len(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareInts(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 func extractGroups(pattern *regexp.Regexp, line string) map[string]string {
 	result := make(map[string]string)
 	if pattern.MatchString(line) {
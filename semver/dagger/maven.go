@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+import "github.com/antchfx/xmlquery"
+
+type pomModel struct {
+	artifactId    string
+	version       string
+	parentVersion string
+	properties    map[string]string
+	modules       []string
+}
+
+var pomPropertyPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+func (r *Semver) getVersionFromPomXml(ctx context.Context, src *Directory) *string {
+	contents, err := src.File("pom.xml").Contents(ctx)
+	if err != nil {
+		fmt.Println("Cannot find a pom.xml")
+		return nil
+	}
+	pom, err := parsePom(contents)
+	if err != nil {
+		fmt.Println("Cannot parse pom.xml")
+		fmt.Println(err)
+		return nil
+	}
+
+	version, err := pom.resolveVersion(r.getMavenConfigProperties(ctx, src), nil)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	return &version
+}
+
+// getMavenConfigProperties reads the -Dkey=value options of .mvn/maven.config
+func (r *Semver) getMavenConfigProperties(ctx context.Context, src *Directory) map[string]string {
+	contents, err := src.File(".mvn/maven.config").Contents(ctx)
+	if err != nil {
+		return nil
+	}
+	return parseMavenConfig(contents)
+}
+
+func parseMavenConfig(contents string) map[string]string {
+	props := make(map[string]string)
+	fields := strings.Fields(contents)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if field == "-D" && i+1 < len(fields) {
+			i++
+			field = "-D" + fields[i]
+		}
+		if prop, ok := strings.CutPrefix(field, "-D"); ok {
+			key, value, _ := strings.Cut(prop, "=")
+			props[key] = strings.Trim(value, `"'`)
+		}
+	}
+	return props
+}
+
+func parsePom(contents string) (*pomModel, error) {
+	root, err := xmlquery.Parse(strings.NewReader(contents))
+	if err != nil {
+		return nil, err
+	}
+	project := xmlquery.FindOne(root, "/project")
+	if project == nil {
+		return nil, errors.New("Missing <project> element")
+	}
+
+	pom := &pomModel{
+		artifactId:    innerText(xmlquery.FindOne(project, "artifactId")),
+		version:       innerText(xmlquery.FindOne(project, "version")),
+		parentVersion: innerText(xmlquery.FindOne(project, "parent/version")),
+		properties:    make(map[string]string),
+	}
+	if properties := xmlquery.FindOne(project, "properties"); properties != nil {
+		for prop := properties.FirstChild; prop != nil; prop = prop.NextSibling {
+			if prop.Type == xmlquery.ElementNode {
+				pom.properties[prop.Data] = strings.TrimSpace(prop.InnerText())
+			}
+		}
+	}
+	for _, module := range xmlquery.Find(project, "modules/module") {
+		pom.modules = append(pom.modules, strings.TrimSpace(module.InnerText()))
+	}
+	return pom, nil
+}
+
+// innerText is the trimmed text of an element, empty when the element is missing
+func innerText(node *xmlquery.Node) string {
+	if node == nil {
+		return ""
+	}
+	return strings.TrimSpace(node.InnerText())
+}
+
+// resolveVersion returns the project version, falling back to the parent version, with ${...} properties resolved.
+// Properties come from maven.config first, then the pom itself, then its parents.
+func (pom *pomModel) resolveVersion(config map[string]string, inherited map[string]string) (string, error) {
+	version := pom.version
+	if version == "" {
+		version = pom.parentVersion
+	}
+	if version == "" {
+		return "", errors.New(fmt.Sprintf("Cannot find a version in pom.xml of %s", pom.artifactId))
+	}
+
+	props := pom.mergeProperties(inherited)
+	props["project.parent.version"] = pom.parentVersion
+	for key, value := range config {
+		props[key] = value
+	}
+
+	// Properties may reference each other, but never more than a few levels deep
+	for i := 0; i < 10 && strings.Contains(version, "${"); i++ {
+		version = pomPropertyPattern.ReplaceAllStringFunc(version, func(ref string) string {
+			if value, ok := props[ref[2:len(ref)-1]]; ok {
+				return value
+			}
+			return ref
+		})
+	}
+	if strings.Contains(version, "${") {
+		return "", errors.New(fmt.Sprintf("Cannot resolve version %s in pom.xml of %s", version, pom.artifactId))
+	}
+	return version, nil
+}
+
+func (pom *pomModel) mergeProperties(inherited map[string]string) map[string]string {
+	props := make(map[string]string)
+	for key, value := range inherited {
+		props[key] = value
+	}
+	for key, value := range pom.properties {
+		props[key] = value
+	}
+	return props
+}
+
+func walkPomModules(readFile func(string) (string, error), pomPath string, config map[string]string, inherited map[string]string) ([]*ModuleVersion, error) {
+	contents, err := readFile(pomPath)
+	if err != nil {
+		return nil, errors.Join(errors.New(fmt.Sprintf("Cannot read %s", pomPath)), err)
+	}
+	pom, err := parsePom(contents)
+	if err != nil {
+		return nil, errors.Join(errors.New(fmt.Sprintf("Cannot parse %s", pomPath)), err)
+	}
+	version, err := pom.resolveVersion(config, inherited)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := []*ModuleVersion{{Path: pomPath, Name: pom.artifactId, Version: version}}
+
+	props := pom.mergeProperties(inherited)
+	props["project.version"] = version
+	for _, module := range pom.modules {
+		modulePath := path.Join(path.Dir(pomPath), module)
+		if !strings.HasSuffix(modulePath, ".xml") {
+			modulePath = path.Join(modulePath, "pom.xml")
+		}
+		children, err := walkPomModules(readFile, modulePath, config, props)
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, children...)
+	}
+	return modules, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// prereleaseRule maps the branches matching pattern to a prerelease channel, an empty channel is a release
+type prereleaseRule struct {
+	pattern *regexp.Regexp
+	channel string
+}
+
+var identifierInvalidChars = regexp.MustCompile(`[^0-9A-Za-z-]+`)
+
+// parsePrereleasePolicy parses rules of the form pattern=channel, e.g. main=, develop=beta, feature/*=alpha.{branch}.
+// A * in the pattern matches any run of characters, slashes included.
+func parsePrereleasePolicy(rules []string) ([]prereleaseRule, error) {
+	var parsed []prereleaseRule
+	for _, rule := range rules {
+		pattern, channel, ok := strings.Cut(rule, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, errors.New(fmt.Sprintf("Invalid prerelease policy rule %q, expected pattern=channel", rule))
+		}
+		expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, `.*`)
+		parsed = append(parsed, prereleaseRule{
+			pattern: regexp.MustCompile("^" + expr + "$"),
+			channel: strings.TrimSpace(channel),
+		})
+	}
+	return parsed, nil
+}
+
+// prereleaseChannel returns the sanitized channel of the first rule matching branch
+func prereleaseChannel(rules []prereleaseRule, branch string) (string, error) {
+	for _, rule := range rules {
+		if !rule.pattern.MatchString(branch) {
+			continue
+		}
+		channel := strings.ReplaceAll(rule.channel, "{branch}", sanitizeIdentifier(branch))
+		return sanitizeIdentifiers(channel), nil
+	}
+	return "", errors.New(fmt.Sprintf("No prerelease policy rule matches the branch %s", branch))
+}
+
+// sanitizeIdentifier turns s into a single SemVer identifier, e.g. feature/JIRA-12_login becomes feature-JIRA-12-login
+func sanitizeIdentifier(s string) string {
+	id := strings.Trim(identifierInvalidChars.ReplaceAllString(s, "-"), "-")
+	// Numeric identifiers must not have leading zeros
+	if isNumeric(id) {
+		id = strings.TrimLeft(id, "0")
+		if id == "" {
+			id = "0"
+		}
+	}
+	return id
+}
+
+// sanitizeIdentifiers sanitizes every dot separated identifier of s, dropping the empty ones
+func sanitizeIdentifiers(s string) string {
+	var ids []string
+	for _, id := range strings.Split(s, ".") {
+		if id = sanitizeIdentifier(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return strings.Join(ids, ".")
+}
+
+// nextPrereleaseNumber returns one more than the highest N of the tags <prefix><base>-<channel>.N, 1 without any
+func nextPrereleaseNumber(tags []string, prefix string, base *Version, channel string) int {
+	next := 1
+	for _, tag := range tags {
+		ver, ok := strings.CutPrefix(tag, prefix)
+		if !ok || !semverPattern.MatchString(ver) {
+			continue
+		}
+		parsed, err := (&Semver{}).Parse(ver)
+		if err != nil || parsed.Maj != base.Maj || parsed.Min != base.Min || parsed.Patch != base.Patch {
+			continue
+		}
+		counter, ok := strings.CutPrefix(parsed.Prerelease, channel+".")
+		if !ok || !isNumeric(counter) {
+			continue
+		}
+		if n, err := strconv.Atoi(counter); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	return next
+}
+
+// applyPrereleasePolicy returns ver in the prerelease channel the policy assigns to branch, as is on a release channel.
+// The branch defaults to the one checked out.
+func (r *Semver) applyPrereleasePolicy(ctx context.Context, gitSrc *gitSource, ver *Version, policy []string, branch string, tagPrefix string) (*Version, error) {
+	rules, err := parsePrereleasePolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := gitSrc.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if branch == "" {
+		head, err := readGitHead(repo)
+		if err != nil {
+			return nil, err
+		}
+		if head.Branch == "" {
+			return nil, errors.New("HEAD is detached, pass the branch to apply the prerelease policy for")
+		}
+		branch = head.Branch
+	}
+
+	channel, err := prereleaseChannel(rules, branch)
+	if err != nil {
+		return nil, err
+	}
+	if channel == "" {
+		return ver, nil
+	}
+
+	tags, err := gitTags(repo, "")
+	if err != nil {
+		return nil, err
+	}
+	n := nextPrereleaseNumber(tagNames(tags), tagPrefix, ver, channel)
+	return &Version{
+		Maj:        ver.Maj,
+		Min:        ver.Min,
+		Patch:      ver.Patch,
+		Prerelease: fmt.Sprintf("%s.%d", channel, n),
+		Build:      ver.Build,
+	}, nil
+}
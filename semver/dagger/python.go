@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+import "github.com/BurntSushi/toml"
+import "gobn.github.io/coalesce"
+
+type pyProject struct {
+	Project struct {
+		Version string
+	}
+	Tool struct {
+		Poetry struct {
+			Version string
+		}
+	}
+}
+
+var pythonVersionAssignment = regexp.MustCompile(`(?m)^__version__\s*(?::\s*str\s*)?=\s*["'](?P<version>[^"']+)["']`)
+var pep440Pattern = regexp.MustCompile(`(?i)^v?(?:(?P<epoch>\d+)!)?(?P<release>\d+(?:\.\d+)*)(?:[-_.]?(?P<pre>alpha|a|beta|b|preview|pre|c|rc)[-_.]?(?P<prenum>\d+)?)?(?P<post>-(?P<postnum1>\d+)|[-_.]?(?:post|rev|r)[-_.]?(?P<postnum2>\d+)?)?(?P<dev>[-_.]?dev[-_.]?(?P<devnum>\d+)?)?(?:\+(?P<local>[a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+func (r *Semver) getVersionFromPython(ctx context.Context, src *Directory, versionFile string) *string {
+	version := coalesce.String(
+		r.getVersionFromPythonModule(ctx, src, versionFile),
+		r.getVersionFromPyProject(ctx, src),
+		r.getVersionFromSetupCfg(ctx, src),
+	)
+	if version == nil {
+		return nil
+	}
+
+	ver, err := pep440ToSemver(*version)
+	if err != nil {
+		fmt.Println(err)
+		return version
+	}
+	return &ver
+}
+
+func (r *Semver) getVersionFromPythonModule(ctx context.Context, src *Directory, versionFile string) *string {
+	if versionFile == "" {
+		return nil
+	}
+	contents, err := src.File(versionFile).Contents(ctx)
+	if err != nil {
+		fmt.Printf("Cannot find %s\n", versionFile)
+		return nil
+	}
+	match := pythonVersionAssignment.FindStringSubmatch(contents)
+	if match == nil {
+		fmt.Printf("Cannot find __version__ in %s\n", versionFile)
+		return nil
+	}
+	return &match[1]
+}
+
+func (r *Semver) getVersionFromPyProject(ctx context.Context, src *Directory) *string {
+	contents, err := src.File("pyproject.toml").Contents(ctx)
+	if err != nil {
+		fmt.Println("Cannot find a pyproject.toml")
+		return nil
+	}
+	var project pyProject
+	if _, err := toml.Decode(contents, &project); err != nil {
+		fmt.Println("Cannot parse pyproject.toml")
+		fmt.Println(err)
+		return nil
+	}
+
+	if project.Project.Version != "" {
+		return &project.Project.Version
+	}
+	if project.Tool.Poetry.Version != "" {
+		return &project.Tool.Poetry.Version
+	}
+	fmt.Println("Cannot find a version in pyproject.toml")
+	return nil
+}
+
+func (r *Semver) getVersionFromSetupCfg(ctx context.Context, src *Directory) *string {
+	contents, err := src.File("setup.cfg").Contents(ctx)
+	if err != nil {
+		fmt.Println("Cannot find a setup.cfg")
+		return nil
+	}
+	version, ok := parseIniSection(contents, "metadata")["version"]
+	if !ok || version == "" {
+		fmt.Println("Cannot find a version in setup.cfg")
+		return nil
+	}
+
+	// setuptools may point the version to a file or a module attribute
+	if file, ok := strings.CutPrefix(version, "file:"); ok {
+		contents, err := src.File(strings.TrimSpace(file)).Contents(ctx)
+		if err != nil {
+			fmt.Printf("Cannot find %s\n", file)
+			return nil
+		}
+		version = strings.TrimSpace(contents)
+		return &version
+	}
+	if attr, ok := strings.CutPrefix(version, "attr:"); ok {
+		module := strings.TrimSuffix(strings.TrimSpace(attr), ".__version__")
+		modulePath := strings.ReplaceAll(module, ".", "/")
+		for _, candidate := range []string{
+			path.Join(modulePath, "__init__.py"),
+			modulePath + ".py",
+			path.Join("src", modulePath, "__init__.py"),
+			path.Join("src", modulePath+".py"),
+		} {
+			if version := r.getVersionFromPythonModule(ctx, src, candidate); version != nil {
+				return version
+			}
+		}
+		return nil
+	}
+	return &version
+}
+
+// parseIniSection returns the keys of one section of an INI file such as setup.cfg
+func parseIniSection(contents string, section string) map[string]string {
+	values := make(map[string]string)
+	current := ""
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if current != section {
+			continue
+		}
+		if key, value, found := strings.Cut(line, "="); found {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
+// pep440ToSemver translates a PEP 440 version, e.g. 1.2.0rc1 -> 1.2.0-rc.1.
+// A dev release gets a leading 0 so that it sorts below every prerelease, 1.2.0.dev1 -> 1.2.0-0.dev.1.
+// The dev release of a prerelease cannot sort below it, 1.2.0rc1.dev1 -> 1.2.0-rc.1.dev.1 sorts above 1.2.0-rc.1.
+// Post releases and local versions end up in the build metadata, where they do not take part in the precedence.
+func pep440ToSemver(ver string) (string, error) {
+	groups := extractGroups(pep440Pattern, strings.TrimSpace(ver))
+	if len(groups) == 0 {
+		return "", errors.New(fmt.Sprintf("Cannot parse PEP 440 version %s", ver))
+	}
+	if epoch, _ := strconv.Atoi(groups["epoch"]); epoch != 0 {
+		return "", errors.New(fmt.Sprintf("Cannot translate PEP 440 epoch of %s", ver))
+	}
+
+	var release [3]int
+	parts := strings.Split(groups["release"], ".")
+	if len(parts) > len(release) {
+		return "", errors.New(fmt.Sprintf("Cannot translate PEP 440 version %s with more than 3 release components", ver))
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return "", err
+		}
+		release[i] = n
+	}
+
+	var prerelease []string
+	if pre := strings.ToLower(groups["pre"]); pre != "" {
+		switch pre {
+		case "a", "alpha":
+			pre = "alpha"
+		case "b", "beta":
+			pre = "beta"
+		default:
+			pre = "rc"
+		}
+		prerelease = append(prerelease, pre, pep440Number(groups["prenum"]))
+	}
+	if groups["dev"] != "" {
+		if len(prerelease) == 0 {
+			prerelease = append(prerelease, "0")
+		}
+		prerelease = append(prerelease, "dev", pep440Number(groups["devnum"]))
+	}
+
+	var build []string
+	if groups["post"] != "" {
+		build = append(build, "post", pep440Number(groups["postnum1"]+groups["postnum2"]))
+	}
+	if local := groups["local"]; local != "" {
+		build = append(build, strings.NewReplacer("_", ".", "-", ".").Replace(strings.ToLower(local)))
+	}
+
+	return (&Semver{}).Build(release[0], release[1], release[2], strings.Join(prerelease, "."), strings.Join(build, ".")), nil
+}
+
+// pep440Number normalizes an implicit or zero-padded PEP 440 number, e.g. rc -> rc.0, rc01 -> rc.1
+func pep440Number(num string) string {
+	n, _ := strconv.Atoi(num)
+	return strconv.Itoa(n)
+}
+
+// semverToPep440 translates a version for Python packaging, e.g. 1.2.0-rc.1 -> 1.2.0rc1.
+// Only alpha, beta, rc and dev prereleases have a PEP 440 equivalent.
+func semverToPep440(ver *Version) (string, error) {
+	pep440 := fmt.Sprintf("%d.%d.%d", ver.Maj, ver.Min, ver.Patch)
+
+	ids := strings.Split(ver.Prerelease, ".")
+	if ver.Prerelease == "" {
+		ids = nil
+	}
+	next := func() string {
+		if len(ids) > 0 && isNumeric(ids[0]) {
+			num := ids[0]
+			ids = ids[1:]
+			return num
+		}
+		return "0"
+	}
+	if len(ids) > 0 {
+		switch strings.ToLower(ids[0]) {
+		case "a", "alpha":
+			ids = ids[1:]
+			pep440 += "a" + next()
+		case "b", "beta":
+			ids = ids[1:]
+			pep440 += "b" + next()
+		case "c", "rc", "pre", "preview":
+			ids = ids[1:]
+			pep440 += "rc" + next()
+		}
+	}
+	// The leading 0 of a dev release translated by pep440ToSemver
+	if len(ids) > 1 && ids[0] == "0" && strings.ToLower(ids[1]) == "dev" {
+		ids = ids[1:]
+	}
+	if len(ids) > 0 && strings.ToLower(ids[0]) == "dev" {
+		ids = ids[1:]
+		pep440 += ".dev" + next()
+	}
+	if len(ids) > 0 {
+		return "", errors.New(fmt.Sprintf("Cannot translate prerelease %s to PEP 440", ver.Prerelease))
+	}
+
+	local := strings.Split(ver.Build, ".")
+	if len(local) >= 2 && local[0] == "post" && isNumeric(local[1]) {
+		pep440 += ".post" + local[1]
+		local = local[2:]
+	}
+	if ver.Build != "" && len(local) > 0 {
+		pep440 += "+" + strings.ToLower(strings.ReplaceAll(strings.Join(local, "."), "-", "."))
+	}
+	return pep440, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Render converts a version to the native format of an ecosystem:
+// semver, pep440 (python), maven, nuget, rpm, deb (debian) or oci (docker)
+func (r *Semver) Render(
+	version string,
+	// Target ecosystem
+	ecosystem string,
+) (string, error) {
+	ver, err := r.Parse(version)
+	if err != nil {
+		return "", err
+	}
+	core := fmt.Sprintf("%d.%d.%d", ver.Maj, ver.Min, ver.Patch)
+
+	switch strings.ToLower(ecosystem) {
+	case "semver":
+		return ver.String(), nil
+	case "pep440", "python":
+		return semverToPep440(ver)
+	case "maven":
+		// Development builds are snapshots, build metadata has no meaning to Maven
+		pre := strings.ToLower(ver.Prerelease)
+		if strings.Contains(pre, "snapshot") || pre == "dev" || strings.HasPrefix(pre, "dev.") || strings.HasPrefix(pre, "0.dev") {
+			return core + "-SNAPSHOT", nil
+		}
+		return r.Build(ver.Maj, ver.Min, ver.Patch, ver.Prerelease, ""), nil
+	case "nuget":
+		// NuGet ignores build metadata when comparing and normalizing package versions
+		return r.Build(ver.Maj, ver.Min, ver.Patch, ver.Prerelease, ""), nil
+	case "rpm", "deb", "debian":
+		// A ~ sorts before the release in both rpm and dpkg, a - is reserved for the release/revision
+		rendered := core
+		if ver.Prerelease != "" {
+			rendered += "~" + strings.ReplaceAll(ver.Prerelease, "-", ".")
+		}
+		if ver.Build != "" {
+			rendered += "+" + strings.ReplaceAll(ver.Build, "-", ".")
+		}
+		return rendered, nil
+	case "oci", "docker":
+		// Tags cannot contain a +, nor be longer than 128 characters
+		rendered := strings.ReplaceAll(ver.String(), "+", "_")
+		if len(rendered) > 128 {
+			rendered = rendered[:128]
+		}
+		return rendered, nil
+	default:
+		return "", errors.New(fmt.Sprintf("Unknown ecosystem: %s", ecosystem))
+	}
+}
@@ -0,0 +1,689 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// A manifestEditor rewrites the version of one kind of manifest and tells whether it holds one.
+// A manifest already at the version is found but left unchanged.
+type manifestEditor func(contents string, ver *Version) (string, bool, error)
+
+var tomlSectionPattern = regexp.MustCompile(`^\s*\[\s*([^\[\]]+?)\s*\]\s*(?:#.*)?$`)
+var tomlVersionPattern = regexp.MustCompile(`^(\s*version\s*=\s*)(["'])([^"']*)(["'].*)$`)
+var iniVersionPattern = regexp.MustCompile(`^(\s*version\s*[=:]\s*)(.*?)(\s*)$`)
+var propertiesVersionPattern = regexp.MustCompile(`^(\s*version\s*[=:]\s*)(.*?)(\s*)$`)
+var gradleLiteralVersionPattern = regexp.MustCompile(`^(\s*(?:project\.)?version\s*(?:=\s*|\s+))(["'])([^"'$]*)(["'].*)$`)
+var chartFieldPatterns = map[string]*regexp.Regexp{
+	"version":    regexp.MustCompile(`(?m)^(version:[ \t]*)(["']?)([^"'\s#]*)(["']?)`),
+	"appVersion": regexp.MustCompile(`(?m)^(appVersion:[ \t]*)(["']?)([^"'\s#]*)(["']?)`),
+}
+
+// SetVersion writes the version into every supported manifest of the directory, keeping their formatting and comments
+func (r *Semver) SetVersion(ctx context.Context,
+	// Path to the source directory
+	src *Directory,
+
+	// Version to write
+	version string,
+
+	// Chart.yaml field to write: version (the chart) or appVersion (the deployed image)
+	// +optional
+	// +default="version"
+	chartField string,
+
+	// Python module holding a __version__ assignment, e.g. src/pkg/__init__.py
+	// +optional
+	pythonVersionFile string,
+) (*Directory, error) {
+	ver, err := r.Parse(version)
+	if err != nil {
+		return nil, err
+	}
+	if chartField == "" {
+		chartField = "version"
+	}
+	chartPattern, ok := chartFieldPatterns[chartField]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("Unknown Chart.yaml field: %s", chartField))
+	}
+
+	editors := map[string]manifestEditor{
+		"package.json":        editPackageJson,
+		"package-lock.json":   editPackageLock,
+		"npm-shrinkwrap.json": editPackageLock,
+		"Chart.yaml": func(contents string, ver *Version) (string, bool, error) {
+			edited, found := replaceLineValue(chartPattern, contents, ver.String())
+			return edited, found, nil
+		},
+		"gradle.properties":     editGradleProperties,
+		"build.gradle":          editGradleScript,
+		"build.gradle.kts":      editGradleScript,
+		"pyproject.toml":        editPyProject,
+		"setup.cfg":             editSetupCfg,
+		"Cargo.toml":            editCargoToml,
+		"Directory.Build.props": editMsbuildProject,
+	}
+	if pythonVersionFile != "" {
+		editors[pythonVersionFile] = editPythonModule
+	}
+	// Projects are commonly nested, e.g. under src/
+	dotnetEditors := map[string]manifestEditor{
+		"*.csproj":                 editMsbuildProject,
+		"**/*.csproj":              editMsbuildProject,
+		"*.nuspec":                 editNuspec,
+		"**/*.nuspec":              editNuspec,
+		"**/Directory.Build.props": editMsbuildProject,
+	}
+	for pattern, editor := range dotnetEditors {
+		matches, err := src.Glob(ctx, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			if !isDotnetOutput(match) {
+				editors[match] = editor
+			}
+		}
+	}
+
+	// Before the root Cargo.toml is edited, as the members are compared with its version
+	src, updated, err := r.setCargoMemberVersions(ctx, src, ver)
+	if err != nil {
+		return nil, err
+	}
+
+	for file, editor := range editors {
+		contents, err := src.File(file).Contents(ctx)
+		if err != nil {
+			continue
+		}
+		edited, found, err := editor(contents, ver)
+		if err != nil {
+			return nil, errors.Join(errors.New(fmt.Sprintf("Cannot set version in %s", file)), err)
+		}
+		if !found {
+			continue
+		}
+		// Counted even when already at the version, so that setting it again succeeds
+		updated++
+		if edited != contents {
+			fmt.Printf("Setting version %s in %s\n", version, file)
+			src = src.WithNewFile(file, edited)
+		}
+	}
+
+	src, mavenUpdated, err := r.setMavenVersion(ctx, src, ver)
+	if err != nil {
+		return nil, err
+	}
+	updated += mavenUpdated
+
+	if updated == 0 {
+		return nil, errors.New("Cannot find a manifest to set the version in")
+	}
+	return src, nil
+}
+
+// setCargoMemberVersions writes the version of the workspace member crates that share the version of the root Cargo.toml,
+// or of every member with a version of its own when the root has none. Members inheriting the workspace version are left as is.
+func (r *Semver) setCargoMemberVersions(ctx context.Context, src *Directory, ver *Version) (*Directory, int, error) {
+	contents, err := src.File("Cargo.toml").Contents(ctx)
+	if err != nil {
+		return src, 0, nil
+	}
+	root, err := parseCargoManifest(contents)
+	if err != nil {
+		return nil, 0, errors.Join(errors.New("Cannot parse Cargo.toml"), err)
+	}
+	manifests, err := cargoMemberManifests(root, func(pattern string) ([]string, error) {
+		return src.Glob(ctx, pattern)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	previous := ""
+	if root.Workspace != nil {
+		previous = root.Workspace.Package.Version
+	}
+	if root.Package != nil {
+		if version, ok := root.Package.Version.(string); ok {
+			previous = version
+		}
+	}
+
+	updated := 0
+	for _, manifest := range manifests {
+		memberContents, err := src.File(manifest).Contents(ctx)
+		if err != nil {
+			fmt.Printf("Cannot find crate %s\n", manifest)
+			continue
+		}
+		crate, err := parseCargoManifest(memberContents)
+		if err != nil {
+			return nil, 0, errors.Join(errors.New(fmt.Sprintf("Cannot parse %s", manifest)), err)
+		}
+		if crate.Package == nil {
+			continue
+		}
+		if version, ok := crate.Package.Version.(string); !ok || (previous != "" && version != previous) {
+			continue
+		}
+		edited, found, err := editCargoToml(memberContents, ver)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !found {
+			continue
+		}
+		updated++
+		if edited != memberContents {
+			fmt.Printf("Setting version %s in %s\n", ver.String(), manifest)
+			src = src.WithNewFile(manifest, edited)
+		}
+	}
+	return src, updated, nil
+}
+
+// isDotnetOutput tells whether a file belongs to the bin or obj build output, such as the nuspec generated by dotnet pack
+func isDotnetOutput(file string) bool {
+	for _, dir := range strings.Split(path.Dir(file), "/") {
+		if dir == "bin" || dir == "obj" {
+			return true
+		}
+	}
+	return false
+}
+
+// setMavenVersion writes the version of the root pom.xml, or the properties it is made of, and the parent version of its modules
+func (r *Semver) setMavenVersion(ctx context.Context, src *Directory, ver *Version) (*Directory, int, error) {
+	contents, err := src.File("pom.xml").Contents(ctx)
+	if err != nil {
+		return src, 0, nil
+	}
+	pom, err := parsePom(contents)
+	if err != nil {
+		return nil, 0, errors.Join(errors.New("Cannot parse pom.xml"), err)
+	}
+	if pom.version == "" {
+		fmt.Println("Not setting the version inherited by pom.xml")
+		return src, 0, nil
+	}
+
+	updated := 0
+	write := func(file string, before string, after string, found bool) {
+		if !found {
+			return
+		}
+		updated++
+		if before != after {
+			fmt.Printf("Setting version %s in %s\n", ver.String(), file)
+			src = src.WithNewFile(file, after)
+		}
+	}
+
+	// CI-friendly versions: the first property gets the whole version, the others are emptied
+	if refs := pomPropertyPattern.FindAllStringSubmatch(pom.version, -1); len(refs) > 0 {
+		edited := contents
+		config, configErr := src.File(".mvn/maven.config").Contents(ctx)
+		editedConfig := config
+		properties, options := 0, 0
+		for i, ref := range refs {
+			value := ""
+			if i == 0 {
+				value = ver.String()
+			}
+			found := 0
+			edited, found, err = replaceXmlText(edited, "project/properties/"+ref[1], value, nil)
+			if err != nil {
+				return nil, 0, err
+			}
+			properties += found
+			option := regexp.MustCompile(`(-D\s*` + regexp.QuoteMeta(ref[1]) + `=)("[^"]*"|'[^']*'|\S*)`)
+			options += len(option.FindAllStringIndex(editedConfig, -1))
+			editedConfig = option.ReplaceAllString(editedConfig, "${1}"+value)
+		}
+		write("pom.xml", contents, edited, properties > 0)
+		if configErr == nil {
+			write(".mvn/maven.config", config, editedConfig, options > 0)
+		}
+		return src, updated, nil
+	}
+
+	edited, found, err := replaceXmlText(contents, "project/version", ver.String(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	write("pom.xml", contents, edited, found > 0)
+
+	modules := pom.modules
+	for i := 0; i < len(modules); i++ {
+		modulePath := modules[i]
+		if !strings.HasSuffix(modulePath, ".xml") {
+			modulePath = path.Join(modulePath, "pom.xml")
+		}
+		moduleContents, err := src.File(modulePath).Contents(ctx)
+		if err != nil {
+			fmt.Printf("Cannot find module %s\n", modulePath)
+			continue
+		}
+		module, err := parsePom(moduleContents)
+		if err != nil {
+			return nil, 0, errors.Join(errors.New(fmt.Sprintf("Cannot parse %s", modulePath)), err)
+		}
+		isOld := func(text string) bool { return text == pom.version }
+		edited, parents, err := replaceXmlText(moduleContents, "project/parent/version", ver.String(), isOld)
+		if err != nil {
+			return nil, 0, err
+		}
+		edited, versions, err := replaceXmlText(edited, "project/version", ver.String(), isOld)
+		if err != nil {
+			return nil, 0, err
+		}
+		write(modulePath, moduleContents, edited, parents+versions > 0)
+		for _, child := range module.modules {
+			modules = append(modules, path.Join(path.Dir(modulePath), child))
+		}
+	}
+	return src, updated, nil
+}
+
+func editPackageJson(contents string, ver *Version) (string, bool, error) {
+	edited, found, err := replaceJsonStrings(contents, [][]string{{"version"}}, ver.String())
+	return edited, found > 0, err
+}
+
+func editPackageLock(contents string, ver *Version) (string, bool, error) {
+	edited, found, err := replaceJsonStrings(contents, [][]string{{"version"}, {"packages", "", "version"}}, ver.String())
+	return edited, found > 0, err
+}
+
+func editGradleProperties(contents string, ver *Version) (string, bool, error) {
+	found := false
+	edited := replaceLines(contents, func(line string, _ string) string {
+		if !propertiesVersionPattern.MatchString(line) {
+			return line
+		}
+		found = true
+		return propertiesVersionPattern.ReplaceAllString(line, "${1}"+ver.String()+"${3}")
+	})
+	return edited, found, nil
+}
+
+// editGradleScript only rewrites literal versions, references are left to the properties they point to
+func editGradleScript(contents string, ver *Version) (string, bool, error) {
+	var blocks []string
+	found := false
+	edited := replaceLinesFunc(contents, func(line string) string {
+		scope := strings.Join(blocks, "/")
+		blocks = trackGradleBlocks(blocks, gradleLineCommentPattern.ReplaceAllString(line, "$1"))
+		if (scope != "" && scope != "allprojects" && scope != "subprojects") || !gradleLiteralVersionPattern.MatchString(line) {
+			return line
+		}
+		found = true
+		return gradleLiteralVersionPattern.ReplaceAllString(line, "${1}${2}"+ver.String()+"${4}")
+	})
+	return edited, found, nil
+}
+
+// editPep440Lines replaces the version that versionAt finds in a line with its PEP 440 form.
+// The version is only translated, and can only fail to, when a line holds one.
+func editPep440Lines(contents string, ver *Version, versionAt func(line string, section string) (start int, end int, ok bool)) (string, bool, error) {
+	pep440 := ""
+	var err error
+	edited := replaceLines(contents, func(line string, section string) string {
+		start, end, ok := versionAt(line, section)
+		if !ok || err != nil {
+			return line
+		}
+		if pep440 == "" {
+			if pep440, err = semverToPep440(ver); err != nil {
+				return line
+			}
+		}
+		return line[:start] + pep440 + line[end:]
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return edited, pep440 != "", nil
+}
+
+func editPyProject(contents string, ver *Version) (string, bool, error) {
+	return editPep440Lines(contents, ver, func(line string, section string) (int, int, bool) {
+		loc := tomlVersionPattern.FindStringSubmatchIndex(line)
+		if (section != "project" && section != "tool.poetry") || loc == nil {
+			return 0, 0, false
+		}
+		return loc[6], loc[7], true
+	})
+}
+
+func editSetupCfg(contents string, ver *Version) (string, bool, error) {
+	return editPep440Lines(contents, ver, func(line string, section string) (int, int, bool) {
+		loc := iniVersionPattern.FindStringSubmatchIndex(line)
+		if section != "metadata" || loc == nil {
+			return 0, 0, false
+		}
+		value := line[loc[4]:loc[5]]
+		if strings.HasPrefix(value, "attr:") || strings.HasPrefix(value, "file:") {
+			return 0, 0, false
+		}
+		return loc[4], loc[5], true
+	})
+}
+
+func editPythonModule(contents string, ver *Version) (string, bool, error) {
+	loc := pythonVersionAssignment.FindStringSubmatchIndex(contents)
+	if loc == nil {
+		return contents, false, nil
+	}
+	pep440, err := semverToPep440(ver)
+	if err != nil {
+		return "", false, err
+	}
+	return contents[:loc[2]] + pep440 + contents[loc[3]:], true, nil
+}
+
+func editCargoToml(contents string, ver *Version) (string, bool, error) {
+	found := false
+	edited := replaceLines(contents, func(line string, section string) string {
+		if (section != "package" && section != "workspace.package") || !tomlVersionPattern.MatchString(line) {
+			return line
+		}
+		found = true
+		return tomlVersionPattern.ReplaceAllString(line, "${1}${2}"+ver.String()+"${4}")
+	})
+	return edited, found, nil
+}
+
+func editMsbuildProject(contents string, ver *Version) (string, bool, error) {
+	props := make(map[string]string)
+	if err := readMsbuildProperties(contents, props); err != nil {
+		return "", false, err
+	}
+
+	var err error
+	edited := contents
+	found := 0
+	for _, prop := range []string{"Version", "PackageVersion"} {
+		count := 0
+		if edited, count, err = replaceXmlText(edited, "Project/PropertyGroup/"+prop, ver.String(), nil); err != nil {
+			return "", false, err
+		}
+		found += count
+	}
+	if _, ok := props["VersionPrefix"]; ok {
+		core := (&Semver{}).Build(ver.Maj, ver.Min, ver.Patch, "", "")
+		count := 0
+		if edited, count, err = replaceXmlText(edited, "Project/PropertyGroup/VersionPrefix", core, nil); err != nil {
+			return "", false, err
+		}
+		found += count
+		suffixes := 0
+		if edited, suffixes, err = replaceXmlText(edited, "Project/PropertyGroup/VersionSuffix", ver.Prerelease, nil); err != nil {
+			return "", false, err
+		}
+		// Without a suffix to hold it, the prerelease would be lost and ship as a release
+		if suffixes == 0 && ver.Prerelease != "" {
+			element := fmt.Sprintf("<VersionSuffix>%s</VersionSuffix>", ver.Prerelease)
+			if edited, err = insertXmlAfter(edited, "Project/PropertyGroup/VersionPrefix", element); err != nil {
+				return "", false, err
+			}
+		}
+	}
+	return edited, found > 0, nil
+}
+
+func editNuspec(contents string, ver *Version) (string, bool, error) {
+	edited, found, err := replaceXmlText(contents, "package/metadata/version", ver.String(), func(text string) bool {
+		return !strings.Contains(text, "$")
+	})
+	return edited, found > 0, err
+}
+
+// replaceLineValue replaces the value of the lines matching pattern and tells whether there is one
+func replaceLineValue(pattern *regexp.Regexp, contents string, value string) (string, bool) {
+	return pattern.ReplaceAllString(contents, "${1}${2}"+value+"${4}"), pattern.MatchString(contents)
+}
+
+// replaceLines rewrites each line of an INI or TOML file, knowing the [section] it belongs to
+func replaceLines(contents string, edit func(line string, section string) string) string {
+	section := ""
+	return replaceLinesFunc(contents, func(line string) string {
+		if match := tomlSectionPattern.FindStringSubmatch(line); match != nil {
+			section = match[1]
+			return line
+		}
+		return edit(line, section)
+	})
+}
+
+func replaceLinesFunc(contents string, edit func(line string) string) string {
+	lines := strings.SplitAfter(contents, "\n")
+	for i, line := range lines {
+		body := strings.TrimRight(line, "\r\n")
+		lines[i] = edit(body) + line[len(body):]
+	}
+	return strings.Join(lines, "")
+}
+
+// replaceXmlText replaces the text of the unconditional elements at elemPath, e.g. project/version,
+// and returns how many it found. A self-closing element is an empty one.
+// The optional accept func filters elements by their current text.
+func replaceXmlText(contents string, elemPath string, value string, accept func(text string) bool) (string, int, error) {
+	decoder := xml.NewDecoder(strings.NewReader(contents))
+
+	var stack []string
+	var tags, starts []int64
+	conditional := 0
+	var edits []textEdit
+	found := 0
+	for {
+		tagStart := decoder.InputOffset()
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", 0, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			tags = append(tags, tagStart)
+			starts = append(starts, decoder.InputOffset())
+			if conditional > 0 || hasXmlAttr(t, "Condition") {
+				conditional++
+			}
+		case xml.EndElement:
+			tag := int(tags[len(tags)-1])
+			start := int(starts[len(starts)-1])
+			end := int(decoder.InputOffset())
+			matched := conditional == 0 && strings.Join(stack, "/") == elemPath
+			stack, tags, starts = stack[:len(stack)-1], tags[:len(tags)-1], starts[:len(starts)-1]
+			if conditional > 0 {
+				conditional--
+			}
+			if !matched {
+				continue
+			}
+			if end == start {
+				if accept != nil && !accept("") {
+					continue
+				}
+				found++
+				if value != "" {
+					edits = append(edits, textEdit{tag, end, fmt.Sprintf("<%s>%s</%s>", t.Name.Local, value, t.Name.Local)})
+				}
+				continue
+			}
+			end = strings.LastIndex(contents[:end], "</")
+			text := contents[start:end]
+			trimmed := strings.TrimSpace(text)
+			if accept != nil && !accept(trimmed) {
+				continue
+			}
+			if trimmed != "" {
+				start += strings.Index(text, trimmed)
+				end = start + len(trimmed)
+			}
+			found++
+			edits = append(edits, textEdit{start, end, value})
+		}
+	}
+	return applyEdits(contents, edits), found, nil
+}
+
+// insertXmlAfter inserts an element after the first unconditional element at elemPath, on its own line with the same indentation.
+// It returns the contents unchanged when there is no such element.
+func insertXmlAfter(contents string, elemPath string, element string) (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(contents))
+
+	var stack []string
+	var tags []int64
+	conditional := 0
+	for {
+		tagStart := decoder.InputOffset()
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return contents, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			tags = append(tags, tagStart)
+			if conditional > 0 || hasXmlAttr(t, "Condition") {
+				conditional++
+			}
+		case xml.EndElement:
+			tag := int(tags[len(tags)-1])
+			matched := conditional == 0 && strings.Join(stack, "/") == elemPath
+			stack, tags = stack[:len(stack)-1], tags[:len(tags)-1]
+			if conditional > 0 {
+				conditional--
+			}
+			if !matched {
+				continue
+			}
+			lineStart := strings.LastIndex(contents[:tag], "\n") + 1
+			indent := contents[lineStart:tag]
+			if strings.TrimSpace(indent) != "" {
+				indent = ""
+			}
+			newline := "\n"
+			if strings.Contains(contents, "\r\n") {
+				newline = "\r\n"
+			}
+			end := int(decoder.InputOffset())
+			return contents[:end] + newline + indent + element + contents[end:], nil
+		}
+	}
+}
+
+// replaceJsonStrings replaces the string values at the given key paths, "" standing for an empty key, and returns how many it found
+func replaceJsonStrings(contents string, paths [][]string, value string) (string, int, error) {
+	type frame struct {
+		array     bool
+		key       string
+		expectKey bool
+	}
+	decoder := json.NewDecoder(strings.NewReader(contents))
+	var stack []*frame
+	var ranges [][2]int
+
+	endValue := func() {
+		if len(stack) > 0 && !stack[len(stack)-1].array {
+			stack[len(stack)-1].expectKey = true
+		}
+	}
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", 0, err
+		}
+
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				stack = append(stack, &frame{array: delim == '[', expectKey: delim == '{'})
+			default:
+				stack = stack[:len(stack)-1]
+				endValue()
+			}
+			continue
+		}
+
+		top := len(stack) - 1
+		if top >= 0 && stack[top].expectKey {
+			stack[top].key = tok.(string)
+			stack[top].expectKey = false
+			continue
+		}
+
+		if _, ok := tok.(string); ok {
+			current := make([]string, len(stack))
+			for i, f := range stack {
+				current[i] = f.key
+				if f.array {
+					current[i] = "[]"
+				}
+			}
+			for _, p := range paths {
+				if strings.Join(p, "\x00") == strings.Join(current, "\x00") && len(p) == len(current) {
+					end := int(decoder.InputOffset())
+					start := strings.LastIndex(contents[:end-1], `"`) + 1
+					ranges = append(ranges, [2]int{start, end - 1})
+				}
+			}
+		}
+		endValue()
+	}
+	return replaceRanges(contents, ranges, value), len(ranges), nil
+}
+
+func hasXmlAttr(elem xml.StartElement, name string) bool {
+	for _, attr := range elem.Attr {
+		if attr.Name.Local == name {
+			return true
+		}
+	}
+	return false
+}
+
+// textEdit replaces the contents between start and end with text
+type textEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// applyEdits applies edits sorted by position and not overlapping
+func applyEdits(contents string, edits []textEdit) string {
+	for i := len(edits) - 1; i >= 0; i-- {
+		contents = contents[:edits[i].start] + edits[i].text + contents[edits[i].end:]
+	}
+	return contents
+}
+
+func replaceRanges(contents string, ranges [][2]int, value string) string {
+	edits := make([]textEdit, len(ranges))
+	for i, r := range ranges {
+		edits[i] = textEdit{r[0], r[1], value}
+	}
+	return applyEdits(contents, edits)
+}
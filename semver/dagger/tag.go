@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Tag creates an annotated release tag, refusing versions that do not exceed every existing tag
+func (r *Semver) Tag(ctx context.Context,
+	// Path to the source directory, including .git
+	src *Directory,
+
+	// Version to tag, without the prefix
+	version string,
+
+	// Tag message. Defaults to "Release <tag>"
+	// +optional
+	message string,
+
+	// Prefix of the release tags, e.g. v, api/v, release-
+	// +optional
+	// +default="v"
+	tagPrefix string,
+
+	// Sign the tag with GPG
+	// +optional
+	sign bool,
+
+	// ASCII-armored private GPG key without passphrase, required to sign
+	// +optional
+	signingKey *Secret,
+
+	// Tagger name
+	// +optional
+	// +default="semver"
+	taggerName string,
+
+	// Tagger email
+	// +optional
+	// +default="semver@dagger.io"
+	taggerEmail string,
+) (*Directory, error) {
+	if !r.Validate(version) {
+		return nil, errors.New(fmt.Sprintf("Invalid SemVer: %s", version))
+	}
+	ver, err := r.Parse(version)
+	if err != nil {
+		return nil, err
+	}
+
+	gitSrc := newGitSource(src, false)
+	defer gitSrc.close()
+	repo, err := gitSrc.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := gitTags(repo, "")
+	if err != nil {
+		return nil, err
+	}
+	if latestTag, latest := r.latestTag(tagNames(tags), tagPrefix, true); latest != nil && compareVersions(ver, latest) <= 0 {
+		return nil, errors.New(fmt.Sprintf("Version %s is not greater than the existing tag %s", version, latestTag))
+	}
+
+	tag := tagPrefix + version
+	if message == "" {
+		message = "Release " + tag
+	}
+	if taggerName == "" {
+		taggerName = "semver"
+	}
+	if taggerEmail == "" {
+		taggerEmail = "semver@dagger.io"
+	}
+
+	ctr := gitContainer(src).
+		WithEnvVariable("GIT_COMMITTER_NAME", taggerName).
+		WithEnvVariable("GIT_COMMITTER_EMAIL", taggerEmail).
+		WithEnvVariable("TAG", tag).
+		WithEnvVariable("TAG_MESSAGE", message)
+
+	if sign {
+		if signingKey == nil {
+			return nil, errors.New("A signing key is required to sign the tag")
+		}
+		ctr = ctr.WithExec([]string{"apk", "add", "gnupg"}).
+			WithMountedSecret("/tmp/signing.key", signingKey).
+			WithExec([]string{"sh", "-ec", `
+				gpg --batch --import /tmp/signing.key
+				key=$(gpg --list-secret-keys --with-colons | awk -F: '/^sec/ { print $5; exit }')
+				git -c user.signingkey="${key}" tag -s "${TAG}" -m "${TAG_MESSAGE}"
+			`})
+	} else {
+		ctr = ctr.WithExec([]string{"sh", "-ec", `git tag -a "${TAG}" -m "${TAG_MESSAGE}"`})
+	}
+
+	return ctr.Directory("/src"), nil
+}